@@ -0,0 +1,163 @@
+package envoy
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSampleGap is the longest gap between samples an Aggregator integrates across unless told otherwise
+const DefaultMaxSampleGap = 10 * time.Minute
+
+// EnergyTotals is the energy produced, consumed, imported and exported over one hour or day
+type EnergyTotals struct {
+	// Start and End delimit the period in the Aggregator's location. Days are 23 or 25 hours long across DST
+	// transitions.
+	Start, End  time.Time
+	Production  WattHours
+	Consumption WattHours
+	Import      WattHours
+	Export      WattHours
+}
+
+// Aggregator rolls Readings into hourly and daily EnergyTotals, aligned to local hours and midnights. Power is
+// integrated between successive samples, split at period boundaries. The zero value aggregates in UTC.
+type Aggregator struct {
+	// Location is where hours and days are aligned, typically the Envoy's from Client.Location; UTC if nil
+	Location *time.Location
+	// MaxGap is the longest gap between samples that is integrated, DefaultMaxSampleGap if zero. Energy is lost
+	// over longer gaps rather than guessed.
+	MaxGap time.Duration
+
+	mu    sync.Mutex
+	last  *Readings
+	hours map[int64]*EnergyTotals
+	days  map[int64]*EnergyTotals
+}
+
+// Observe adds the Readings value of *u*, e.g. from a Poller subscription. Other values and failed polls are
+// ignored.
+func (a *Aggregator) Observe(u Update) {
+	if r, ok := u.Value.(Readings); ok && u.Err == nil {
+		a.Add(r)
+	}
+}
+
+// Add integrates the power reported by *r* since the previous Readings. Readings older than the previous one are
+// ignored.
+func (a *Aggregator) Add(r Readings) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.hours == nil {
+		a.hours = make(map[int64]*EnergyTotals)
+		a.days = make(map[int64]*EnergyTotals)
+	}
+	prev := a.last
+	if prev != nil && !r.Time.After(prev.Time) {
+		return
+	}
+	a.last = &r
+	gap := a.MaxGap
+	if gap <= 0 {
+		gap = DefaultMaxSampleGap
+	}
+	if prev == nil || r.Time.Sub(prev.Time) > gap {
+		return
+	}
+	// the power of the previous sample holds until this one
+	for from := prev.Time; from.Before(r.Time); {
+		hour := a.period(a.hours, from, hourStart, nextHour)
+		day := a.period(a.days, from, dayStart, nextDay)
+		to := r.Time
+		if hour.End.Before(to) {
+			to = hour.End
+		}
+		if day.End.Before(to) {
+			to = day.End
+		}
+		hours := to.Sub(from).Hours()
+		for _, t := range []*EnergyTotals{hour, day} {
+			t.Production += WattHours(float64(prev.Production) * hours)
+			if prev.HasConsumption {
+				t.Consumption += WattHours(float64(prev.Consumption) * hours)
+				if prev.Net > 0 {
+					t.Import += WattHours(float64(prev.Net) * hours)
+				} else {
+					t.Export += WattHours(float64(-prev.Net) * hours)
+				}
+			}
+		}
+		from = to
+	}
+}
+
+// period returns the totals of the period containing *t*, creating them if needed
+func (a *Aggregator) period(periods map[int64]*EnergyTotals, t time.Time, start, next func(time.Time) time.Time) *EnergyTotals {
+	loc := a.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	s := start(t.In(loc))
+	p, ok := periods[s.Unix()]
+	if !ok {
+		p = &EnergyTotals{Start: s, End: next(s)}
+		periods[s.Unix()] = p
+	}
+	return p
+}
+
+// hourStart returns the start of the local hour containing *t*. Subtracting the minutes rather than building the
+// time from its fields keeps the repeated hour of a DST transition apart.
+func hourStart(t time.Time) time.Time {
+	return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second -
+		time.Duration(t.Nanosecond()))
+}
+
+func nextHour(start time.Time) time.Time {
+	return start.Add(time.Hour)
+}
+
+// dayStart returns the local midnight starting the day containing *t*
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func nextDay(start time.Time) time.Time {
+	return time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+}
+
+// Hourly returns the hourly totals, oldest first
+func (a *Aggregator) Hourly() []EnergyTotals {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return sortedTotals(a.hours)
+}
+
+// Daily returns the daily totals, oldest first
+func (a *Aggregator) Daily() []EnergyTotals {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return sortedTotals(a.days)
+}
+
+// Prune drops the totals of periods that ended before *before*
+func (a *Aggregator) Prune(before time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, periods := range []map[int64]*EnergyTotals{a.hours, a.days} {
+		for k, p := range periods {
+			if p.End.Before(before) {
+				delete(periods, k)
+			}
+		}
+	}
+}
+
+func sortedTotals(periods map[int64]*EnergyTotals) []EnergyTotals {
+	totals := make([]EnergyTotals, 0, len(periods))
+	for _, p := range periods {
+		totals = append(totals, *p)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Start.Before(totals[j].Start) })
+	return totals
+}
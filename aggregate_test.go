@@ -0,0 +1,118 @@
+package envoy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// steady feeds *a* samples of 1 kW production every *step* from *from* to *to*
+func steady(a *Aggregator, from, to time.Time, step time.Duration) {
+	for at := from; !at.After(to); at = at.Add(step) {
+		a.Add(Readings{Time: at, Production: 1000})
+	}
+}
+
+func near(a, b WattHours) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+func TestAggregatorDSTDays(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		day   time.Time
+		hours int
+	}{
+		"spring forward": {time.Date(2024, 3, 10, 0, 0, 0, 0, loc), 23},
+		"fall back":      {time.Date(2024, 11, 3, 0, 0, 0, 0, loc), 25},
+	} {
+		a := Aggregator{Location: loc, MaxGap: time.Hour}
+		next := time.Date(tc.day.Year(), tc.day.Month(), tc.day.Day()+1, 0, 0, 0, 0, loc)
+		steady(&a, tc.day, next, 10*time.Minute)
+
+		days := a.Daily()
+		if len(days) != 1 {
+			t.Fatalf("%s: got %d days, want 1", name, len(days))
+		}
+		d := days[0]
+		if !d.Start.Equal(tc.day) || !d.End.Equal(next) || d.End.Sub(d.Start) != time.Duration(tc.hours)*time.Hour {
+			t.Errorf("%s: got a day from %v to %v, want %d hours from %v", name, d.Start, d.End, tc.hours, tc.day)
+		}
+		if want := WattHours(1000 * tc.hours); !near(d.Production, want) {
+			t.Errorf("%s: produced %v, want %v", name, float64(d.Production), float64(want))
+		}
+		hours := a.Hourly()
+		if len(hours) != tc.hours {
+			t.Fatalf("%s: got %d hours, want %d", name, len(hours), tc.hours)
+		}
+		for _, h := range hours {
+			if h.End.Sub(h.Start) != time.Hour || !near(h.Production, 1000) {
+				t.Errorf("%s: got %v from %v to %v, want 1 kWh in an hour", name, float64(h.Production), h.Start, h.End)
+			}
+		}
+	}
+}
+
+func TestAggregatorRepeatedHour(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Aggregator{Location: loc}
+	// 1am EDT until 1am EST is the first pass through the hour clocks fall back into
+	first := time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC)
+	steady(&a, first, first.Add(2*time.Hour), 5*time.Minute)
+
+	hours := a.Hourly()
+	if len(hours) != 2 {
+		t.Fatalf("got %d hours, want both 1am hours", len(hours))
+	}
+	for i, h := range hours {
+		if h.Start.Hour() != 1 || !h.Start.Equal(first.Add(time.Duration(i)*time.Hour)) || !near(h.Production, 1000) {
+			t.Errorf("hour %d: got %v from %v, want 1 kWh from the %s 1am", i, float64(h.Production), h.Start,
+				[]string{"first", "second"}[i])
+		}
+	}
+}
+
+func TestAggregatorGap(t *testing.T) {
+	a := Aggregator{MaxGap: 10 * time.Minute}
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	a.Add(Readings{Time: start, Production: 1000})
+	a.Add(Readings{Time: start.Add(6 * time.Minute), Production: 1000})
+	// nothing is counted across a gap longer than MaxGap
+	a.Add(Readings{Time: start.Add(30 * time.Minute), Production: 1000})
+	a.Add(Readings{Time: start.Add(36 * time.Minute), Production: 1000})
+	// and an older sample is ignored
+	a.Add(Readings{Time: start.Add(33 * time.Minute), Production: 5000})
+
+	hours := a.Hourly()
+	if len(hours) != 1 || !near(hours[0].Production, 200) {
+		t.Errorf("got %+v, want 200 Wh from two 6 minute intervals", hours)
+	}
+}
+
+func TestAggregatorSplitsAtHour(t *testing.T) {
+	a := Aggregator{MaxGap: time.Hour}
+	start := time.Date(2024, 6, 1, 12, 50, 0, 0, time.UTC)
+	a.Add(Readings{Time: start, Production: 600, Consumption: 1200, Net: 600, HasConsumption: true})
+	a.Add(Readings{Time: start.Add(20 * time.Minute), Production: 600, Consumption: 1200, Net: 600,
+		HasConsumption: true})
+
+	hours := a.Hourly()
+	if len(hours) != 2 {
+		t.Fatalf("got %d hours, want the sample split across 2", len(hours))
+	}
+	// 10 minutes fall in each hour
+	for i, h := range hours {
+		if !near(h.Production, 100) || !near(h.Consumption, 200) || !near(h.Import, 100) || h.Export != 0 {
+			t.Errorf("hour %d: got %+v, want 100 Wh produced, 200 Wh consumed and 100 Wh imported", i, h)
+		}
+	}
+	if !hours[1].Start.Equal(time.Date(2024, 6, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("second hour starts at %v, want 13:00", hours[1].Start)
+	}
+}
@@ -0,0 +1,249 @@
+package envoy
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Alert sources
+const (
+	// AlertSourceInventory alerts come from device conditions in the inventory
+	AlertSourceInventory = "inventory"
+	// AlertSourceEvents alerts come from the event log
+	AlertSourceEvents = "events"
+	// AlertSourceStale alerts are inverters that stopped reporting
+	AlertSourceStale = "stale"
+)
+
+// DefaultEventAlertTTL is how long an alert raised from the event log stays active unless the event recurs
+const DefaultEventAlertTTL = 24 * time.Hour
+
+// Alert is a fault or warning about a device, deduplicated across polls
+type Alert struct {
+	Severity Severity
+	// Source is one of the AlertSource constants
+	Source string
+	// Serial is the serial number of the device concerned, if known
+	Serial string
+	// Condition is the device condition, the event type or, for stale inverters, AlertSourceStale
+	Condition   string
+	Description string
+	// FirstSeen and LastSeen are when the alert was first and most recently observed
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type alertKey struct {
+	source, serial, condition string
+}
+
+// Alerts turns device conditions, event log entries and stale inverter reports into Alerts, raising each once and
+// clearing it when it goes away. Microinverters stop reporting at night, so their conditions and stale reports are
+// only followed in daylight. The zero value is ready to use.
+type Alerts struct {
+	// MinSeverity is the least severe condition or event that raises an alert. Informational ones never do, so the
+	// zero value means SeverityWarning.
+	MinSeverity Severity
+	// StaleAfter is how long an inverter may go without reporting before it raises an alert, DefaultStaleAfter if
+	// zero
+	StaleAfter time.Duration
+	// EventTTL is how long an alert from the event log stays active, DefaultEventAlertTTL if zero
+	EventTTL time.Duration
+	// Daylight reports whether inverters should be producing at a given time, e.g. SunDaylight for the site. If
+	// nil, it is daylight whenever any inverter is producing. Outside daylight, microinverter alerts are neither
+	// raised nor cleared.
+	Daylight func(time.Time) bool
+
+	mu     sync.Mutex
+	active map[alertKey]*Alert
+}
+
+// Observe scans the value of *u*, which may be an Inventory, a []Inverter or a []Event, and returns the alerts it
+// raised and cleared. Other values and failed polls are ignored.
+func (a *Alerts) Observe(u Update) (raised, cleared []Alert) {
+	if u.Err != nil {
+		return nil, nil
+	}
+	switch v := u.Value.(type) {
+	case Inventory:
+		return a.ObserveInventory(u.Time, v)
+	case []Inverter:
+		return a.ObserveInverters(u.Time, v)
+	case []Event:
+		return a.ObserveEvents(u.Time, v)
+	}
+	return nil, nil
+}
+
+// ObserveInventory raises an alert for each device condition at least MinSeverity, and clears those of the previous
+// inventory that are gone
+func (a *Alerts) ObserveInventory(now time.Time, inv Inventory) (raised, cleared []Alert) {
+	inv = inv.Active()
+	night := !a.daylight(now, func() bool {
+		for _, m := range inv.Microinverters {
+			if m.Producing {
+				return true
+			}
+		}
+		return false
+	})
+	unpowered := make(map[string]bool)
+	for _, m := range inv.Microinverters {
+		unpowered[m.SerialNum] = night
+	}
+
+	found := make(map[alertKey]Alert)
+	a.mu.Lock()
+	for k, alert := range a.active {
+		if k.source == AlertSourceInventory && unpowered[k.serial] {
+			// keep what the microinverter reported in daylight until it reports again
+			found[k] = *alert
+		}
+	}
+	a.mu.Unlock()
+	for _, d := range inv.Devices() {
+		if unpowered[d.SerialNum] {
+			continue
+		}
+		for _, c := range d.DeviceStatus {
+			if s := c.Severity(); s >= a.minSeverity() {
+				k := alertKey{AlertSourceInventory, d.SerialNum, string(c)}
+				found[k] = Alert{Severity: s, Condition: string(c), Description: c.Description()}
+			}
+		}
+	}
+	return a.observe(now, AlertSourceInventory, found, true)
+}
+
+// ObserveInverters raises an alert for each inverter whose last report is older than StaleAfter, and clears it
+// once the inverter reports again. Outside daylight nothing is raised or cleared.
+func (a *Alerts) ObserveInverters(now time.Time, inverters []Inverter) (raised, cleared []Alert) {
+	stale := a.StaleAfter
+	if stale <= 0 {
+		stale = DefaultStaleAfter
+	}
+	if !a.daylight(now, func() bool { return anyProducing(now, inverters, stale) }) {
+		return nil, nil
+	}
+	found := make(map[alertKey]Alert)
+	for _, i := range inverters {
+		if now.Sub(i.LastReportDate.Time) > stale {
+			k := alertKey{AlertSourceStale, i.SerialNumber, AlertSourceStale}
+			found[k] = Alert{Severity: SeverityWarning, Condition: AlertSourceStale, Description: "The inverter has stopped reporting"}
+		}
+	}
+	return a.observe(now, AlertSourceStale, found, true)
+}
+
+// ObserveEvents raises an alert for each event at least MinSeverity. Since the log only records that something
+// happened, such alerts are cleared once EventTTL has passed without the event recurring.
+func (a *Alerts) ObserveEvents(now time.Time, events []Event) (raised, cleared []Alert) {
+	ttl := a.EventTTL
+	if ttl <= 0 {
+		ttl = DefaultEventAlertTTL
+	}
+	found := make(map[alertKey]Alert)
+	for _, e := range events {
+		if e.Severity < a.minSeverity() || (!e.Timestamp.IsZero() && now.Sub(e.Timestamp) > ttl) {
+			continue
+		}
+		k := alertKey{AlertSourceEvents, e.SerialNumber, e.Type}
+		if prev, ok := found[k]; ok && !e.Timestamp.After(prev.LastSeen) {
+			continue
+		}
+		seen := e.Timestamp
+		if seen.IsZero() {
+			seen = now
+		}
+		found[k] = Alert{Severity: e.Severity, Condition: e.Type, Description: e.Description, LastSeen: seen}
+	}
+	raised, _ = a.observe(now, AlertSourceEvents, found, false)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, alert := range a.active {
+		if k.source == AlertSourceEvents && now.Sub(alert.LastSeen) > ttl {
+			cleared = append(cleared, *alert)
+			delete(a.active, k)
+		}
+	}
+	sortAlerts(cleared)
+	return raised, cleared
+}
+
+// daylight reports whether it is daylight at *t*, asking Daylight if set and *producing* otherwise
+func (a *Alerts) daylight(t time.Time, producing func() bool) bool {
+	if a.Daylight != nil {
+		return a.Daylight(t)
+	}
+	return producing()
+}
+
+func (a *Alerts) minSeverity() Severity {
+	if a.MinSeverity < SeverityWarning {
+		return SeverityWarning
+	}
+	return a.MinSeverity
+}
+
+// observe merges the alerts *found* from *source* into the active ones. With *exhaustive* set, active alerts from
+// *source* that were not found are cleared.
+func (a *Alerts) observe(now time.Time, source string, found map[alertKey]Alert, exhaustive bool) (raised, cleared []Alert) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.active == nil {
+		a.active = make(map[alertKey]*Alert)
+	}
+	for k, f := range found {
+		seen := f.LastSeen
+		if seen.IsZero() {
+			seen = now
+		}
+		if alert, ok := a.active[k]; ok {
+			if seen.After(alert.LastSeen) {
+				alert.LastSeen = seen
+			}
+			continue
+		}
+		f.Source, f.Serial, f.FirstSeen, f.LastSeen = source, k.serial, seen, seen
+		a.active[k] = &f
+		raised = append(raised, f)
+	}
+	if exhaustive {
+		for k, alert := range a.active {
+			if _, ok := found[k]; !ok && k.source == source {
+				cleared = append(cleared, *alert)
+				delete(a.active, k)
+			}
+		}
+	}
+	sortAlerts(raised)
+	sortAlerts(cleared)
+	return raised, cleared
+}
+
+// Active returns the alerts currently raised, most severe first
+func (a *Alerts) Active() []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	alerts := make([]Alert, 0, len(a.active))
+	for _, alert := range a.active {
+		alerts = append(alerts, *alert)
+	}
+	sortAlerts(alerts)
+	return alerts
+}
+
+// sortAlerts orders *alerts* most severe first, then by device and condition
+func sortAlerts(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		if alerts[i].Serial != alerts[j].Serial {
+			return alerts[i].Serial < alerts[j].Serial
+		}
+		return alerts[i].Condition < alerts[j].Condition
+	})
+}
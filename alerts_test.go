@@ -0,0 +1,58 @@
+package envoy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlertsStaleOnlyInDaylight(t *testing.T) {
+	var a Alerts
+	noon := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	inverters := func(reported time.Time, watts int) []Inverter {
+		return []Inverter{
+			{SerialNumber: "1", LastReportDate: Timestamp{reported}, LastReportWatts: watts},
+			{SerialNumber: "2", LastReportDate: Timestamp{reported}, LastReportWatts: watts},
+		}
+	}
+
+	dusk := noon.Add(8 * time.Hour)
+	if raised, _ := a.ObserveInverters(dusk.Add(time.Hour), inverters(dusk, 0)); len(raised) != 0 {
+		t.Errorf("raised %+v at night, want nothing", raised)
+	}
+
+	u := inverters(noon, 250)
+	u[1].LastReportDate = Timestamp{noon.Add(-time.Hour)}
+	if raised, _ := a.ObserveInverters(noon, u); len(raised) != 1 || raised[0].Serial != "2" {
+		t.Errorf("raised %+v, want inverter 2 stale", raised)
+	}
+}
+
+func TestAlertsConditionsOnlyInDaylight(t *testing.T) {
+	var a Alerts
+	noon := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	inventory := func(producing bool, status ...Condition) Inventory {
+		return Inventory{Microinverters: []Microinverter{
+			{Device: Device{SerialNum: "1", Producing: producing, DeviceStatus: status}},
+			{Device: Device{SerialNum: "2", Producing: producing, DeviceStatus: Conditions{ConditionPCUGFITripped}}},
+		}}
+	}
+
+	if raised, _ := a.ObserveInventory(noon, inventory(true)); len(raised) != 1 || raised[0].Serial != "2" {
+		t.Fatalf("raised %+v, want inverter 2's ground fault", raised)
+	}
+	night := noon.Add(10 * time.Hour)
+	raised, cleared := a.ObserveInventory(night, inventory(false, ConditionPCUDCPowerLow, ConditionPCUBridgeFault))
+	if len(raised) != 0 || len(cleared) != 0 {
+		t.Errorf("raised %+v and cleared %+v at night, want no change", raised, cleared)
+	}
+	if active := a.Active(); len(active) != 1 || active[0].Serial != "2" {
+		t.Errorf("got %+v active, want inverter 2's ground fault kept overnight", active)
+	}
+}
+
+func TestDCPowerLowIsInfo(t *testing.T) {
+	// every microinverter reports dc-pwr-low at dusk, so it is no cause for alarm
+	if s := ConditionPCUDCPowerLow.Severity(); s != SeverityInfo {
+		t.Errorf("got %v, want SeverityInfo", s)
+	}
+}
@@ -0,0 +1,101 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Data endpoints
+const (
+	inventoryPath  = "/inventory.json"
+	productionPath = "/production.json?details=1"
+	// systemProductionPath is served by every firmware, and is the only production endpoint before 3.9
+	systemProductionPath = "/api/v1/production"
+)
+
+// apiVersion describes how one generation of firmware serves the data the Client models, so methods can route to
+// the right endpoint and decoder and callers need not care which firmware the Envoy runs
+type apiVersion struct {
+	name string
+	// jwt is set when the data endpoints need a JWT session
+	jwt bool
+	// productionPath serves the readings of production.json, or what the firmware has of them, and
+	// decodeProduction decodes its response
+	productionPath   string
+	decodeProduction func(raw []byte) (Production, error)
+}
+
+// The API generations of Envoy firmware
+var (
+	// apiLegacy is firmware before 3.9, which predates production.json and reports only the inverters' totals
+	apiLegacy = apiVersion{name: "legacy", productionPath: systemProductionPath, decodeProduction: decodeSystemProduction}
+	// apiV5 is firmware 3.9 up to 7.0, which serves production.json without authentication
+	apiV5 = apiVersion{name: "5.x", productionPath: productionPath, decodeProduction: decodeProduction}
+	// apiV7 is firmware 7.x and later, which requires a JWT session
+	apiV7 = apiVersion{name: "7.x", jwt: true, productionPath: productionPath, decodeProduction: decodeProduction}
+)
+
+// apiFor picks the API generation of the firmware described by *info*
+func apiFor(info Info) apiVersion {
+	version, err := info.FirmwareVersion()
+	switch {
+	case info.Capabilities().Has(CapabilityJWTAuth):
+		return apiV7
+	case err == nil && !version.AtLeast(3, 9):
+		return apiLegacy
+	}
+	return apiV5
+}
+
+// api returns the API generation of the Envoy, detecting it from /info on first use. If the firmware cannot be
+// detected, it assumes 7.x and leaves the request itself to report any problem.
+func (c *Client) api(ctx context.Context) apiVersion {
+	c.capMu.Lock()
+	detected := c.version
+	c.capMu.Unlock()
+	if detected != nil {
+		return *detected
+	}
+
+	info, err := c.Info(ctx)
+	var apiErr *APIError
+	api := apiV7
+	switch {
+	case err == nil:
+		api = apiFor(info)
+	case errors.As(err, &apiErr):
+		// an Envoy that answers but won't describe itself is not asked again
+	default:
+		// try detecting again on the next request
+		return api
+	}
+	c.capMu.Lock()
+	c.version = &api
+	if err == nil && c.caps == nil {
+		c.caps = info.Capabilities()
+	}
+	c.capMu.Unlock()
+	c.logger.DebugContext(ctx, "detected envoy api", "api", api.name)
+	return api
+}
+
+func decodeProduction(raw []byte) (Production, error) {
+	var production Production
+	err := json.Unmarshal(raw, &production)
+	return production, err
+}
+
+// decodeSystemProduction presents the inverter totals of /api/v1/production as the inverters reading of
+// production.json
+func decodeSystemProduction(raw []byte) (Production, error) {
+	var p SystemProduction
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Production{}, err
+	}
+	return Production{Production: []ProductionData{{
+		Type:       ProductionTypeInverters,
+		WNow:       Watts(p.WattsNow),
+		WhLifetime: WattHours(p.WattHoursLifetime),
+	}}}, nil
+}
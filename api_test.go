@@ -0,0 +1,68 @@
+package envoy
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFirmwareAuth(t *testing.T) {
+	for _, tc := range []struct {
+		firmware string
+		logins   int
+	}{
+		{"R3.8.10", 0},
+		{"R4.10.35", 0},
+		{"D5.0.62", 0},
+		{"D7.0.88", 1},
+	} {
+		t.Run(tc.firmware, func(t *testing.T) {
+			f := newFakeEnvoy(t)
+			f.firmware = tc.firmware
+			f.handleJSON("/production.json", testProduction)
+			f.handleJSON("/api/v1/production", `{"wattsNow":250,"wattHoursLifetime":1200000}`)
+			p, err := f.client().Production(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(p.Production) != 1 || p.Production[0].WNow != 250 {
+				t.Errorf("got production readings %+v, want one of 250 W", p.Production)
+			}
+			if n := f.loginCount(); n != tc.logins {
+				t.Errorf("logged in %d times, want %d", n, tc.logins)
+			}
+		})
+	}
+}
+
+func TestAPIFor(t *testing.T) {
+	for firmware, want := range map[string]string{
+		"R3.7.31": "legacy",
+		"R3.9.36": "5.x",
+		"D5.0.62": "5.x",
+		"D7.0.88": "7.x",
+		"D8.2.4":  "7.x",
+		"":        "5.x",
+	} {
+		info := Info{}
+		info.Device.Software = firmware
+		if got := apiFor(info).name; got != want {
+			t.Errorf("apiFor(%q) = %s, want %s", firmware, got, want)
+		}
+	}
+}
+
+func TestLegacyProduction(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.firmware = "R3.7.31"
+	f.handleJSON("/api/v1/production", `{"wattsNow":250,"wattHoursLifetime":1200000}`)
+	r, err := f.client().Readings(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Production != 250 || r.ProductionLifetime != 1200000 {
+		t.Errorf("got %+v, want 250 W and 1.2 MWh from the inverter totals", r)
+	}
+	if n := f.requestCount("/production.json"); n != 0 {
+		t.Errorf("requested production.json %d times from firmware that lacks it", n)
+	}
+}
@@ -0,0 +1,48 @@
+package envoy
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBatchWorkers is how many fetches Batch runs at once unless told otherwise. The Envoy's small CPU gains
+// little from more.
+const DefaultBatchWorkers = 4
+
+// Batch fetches *sources* in parallel, at most *workers* at a time or DefaultBatchWorkers if *workers* is zero, and
+// returns each result keyed by Source name. All fetches share one deadline: that of *ctx* if it has one, otherwise
+// the Client's timeout. A Source's Interval and Jitter are ignored.
+func (c *Client) Batch(ctx context.Context, workers int, sources ...Source) map[string]Update {
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	results := make(map[string]Update, len(sources))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan Source)
+	for i := 0; i < workers && i < len(sources); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for source := range queue {
+				value, err := source.Fetch(ctx, c)
+				if err != nil {
+					value = nil
+				}
+				mu.Lock()
+				results[source.Name] = Update{Source: source.Name, Time: time.Now(), Value: value, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, source := range sources {
+		queue <- source
+	}
+	close(queue)
+	wg.Wait()
+	return results
+}
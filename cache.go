@@ -0,0 +1,115 @@
+package envoy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCache caches each successful GET response for *ttl*, so several consumers in one process share a poll of the
+// Envoy instead of each making their own. Any call that changes state clears the cache. Use ForceRefresh to bypass
+// it for one call.
+func WithCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.ensureCache().ttl = ttl
+	}
+}
+
+// WithEndpointCache caches GET responses whose path starts with *path* for *ttl* instead of the TTL set by
+// WithCache; a zero *ttl* disables caching of those paths. When several prefixes match, the longest wins.
+func WithEndpointCache(path string, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.ensureCache().ttls[path] = ttl
+	}
+}
+
+func (c *Client) ensureCache() *responseCache {
+	if c.cache == nil {
+		c.cache = &responseCache{ttls: make(map[string]time.Duration), entries: make(map[string]cacheEntry)}
+	}
+	return c.cache
+}
+
+type forceRefreshKey struct{}
+
+// ForceRefresh returns a context under which calls bypass the Client's cache, fetching fresh data and caching it.
+// The Client also passes such a context to its TokenProvider when the Envoy has rejected the current token, so
+// StoredToken fetches a new token instead of loading the rejected one.
+func ForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func forceRefresh(ctx context.Context) bool {
+	force, _ := ctx.Value(forceRefreshKey{}).(bool)
+	return force
+}
+
+// responseCache holds response bodies by URL
+type responseCache struct {
+	ttl  time.Duration
+	ttls map[string]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// ttlFor returns how long responses for *url* are cached
+func (rc *responseCache) ttlFor(url string) time.Duration {
+	path, _, _ := strings.Cut(url, "?")
+	var match string
+	ttl := rc.ttl
+	for prefix, t := range rc.ttls {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(match) {
+			match, ttl = prefix, t
+		}
+	}
+	return ttl
+}
+
+// get returns the cached body for *url*, if it has not expired, dropping it if it has. It is nil-safe.
+func (rc *responseCache) get(url string) ([]byte, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	e, ok := rc.entries[url]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(rc.entries, url)
+		return nil, false
+	}
+	return e.body, true
+}
+
+// put caches *body* for *url*, unless caching is disabled for it. It is nil-safe.
+func (rc *responseCache) put(url string, body []byte) {
+	if rc == nil {
+		return
+	}
+	ttl := rc.ttlFor(url)
+	if ttl <= 0 {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[url] = cacheEntry{body: body, expires: time.Now().Add(ttl)}
+}
+
+// clear drops every cached response. It is nil-safe.
+func (rc *responseCache) clear() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries = make(map[string]cacheEntry)
+}
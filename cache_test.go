@@ -0,0 +1,21 @@
+package envoy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheDropsExpired(t *testing.T) {
+	rc := &responseCache{ttl: time.Millisecond, ttls: make(map[string]time.Duration), entries: make(map[string]cacheEntry)}
+	rc.put("/production.json", []byte("{}"))
+	if _, ok := rc.get("/production.json"); !ok {
+		t.Fatal("fresh entry not returned")
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := rc.get("/production.json"); ok {
+		t.Fatal("expired entry returned")
+	}
+	if n := len(rc.entries); n != 0 {
+		t.Errorf("cache holds %d entries after expiry, want 0", n)
+	}
+}
@@ -0,0 +1,157 @@
+package envoy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Capability names a feature an Envoy may or may not provide, depending on its firmware and hardware
+type Capability string
+
+// Capabilities detected from /info
+const (
+	// CapabilityJWTAuth is set when the Envoy authenticates with JWTs (firmware 7.x and later) rather than digest auth
+	CapabilityJWTAuth Capability = "jwt-auth"
+	// CapabilityMeters is set when the Envoy has integrated production or consumption meters
+	CapabilityMeters Capability = "meters"
+	// CapabilityEnsemble is set when the Envoy manages Encharge batteries or an Enpower system controller
+	CapabilityEnsemble Capability = "ensemble"
+	// CapabilityLiveData is set when the Envoy provides the /ivp/livedata endpoints
+	CapabilityLiveData Capability = "livedata"
+)
+
+// Capabilities that are not detected up front, but are reported when an endpoint reveals they are missing
+const (
+	// CapabilityEncharge is the presence of Encharge batteries
+	CapabilityEncharge Capability = "encharge"
+	// CapabilityEnpower is the presence of an Enpower (IQ System Controller)
+	CapabilityEnpower Capability = "enpower"
+)
+
+// Capabilities records which capabilities an Envoy has
+type Capabilities map[Capability]bool
+
+// Has reports whether *capability* is available
+func (c Capabilities) Has(capability Capability) bool {
+	return c[capability]
+}
+
+// FirmwareVersion is a parsed Envoy firmware version such as D7.0.88
+type FirmwareVersion struct {
+	// Prefix is the letter preceding the version, usually D for production firmware
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+}
+
+// ParseFirmwareVersion parses a version string as reported in /info, e.g. D7.0.88 or R4.10.35
+func ParseFirmwareVersion(s string) (FirmwareVersion, error) {
+	var v FirmwareVersion
+	rest := strings.TrimLeftFunc(s, notDigit)
+	v.Prefix = s[:len(s)-len(rest)]
+	parts := strings.SplitN(rest, ".", 3)
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		// ignore suffixes such as "88-rc1"
+		if end := strings.IndexFunc(part, notDigit); end >= 0 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid firmware version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+func (v FirmwareVersion) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+// AtLeast reports whether v is major.minor or newer
+func (v FirmwareVersion) AtLeast(major, minor int) bool {
+	return v.Major > major || v.Major == major && v.Minor >= minor
+}
+
+// FirmwareVersion parses the firmware version the Envoy reported
+func (i Info) FirmwareVersion() (FirmwareVersion, error) {
+	return ParseFirmwareVersion(i.Device.Software)
+}
+
+// Capabilities derives the Envoy's capabilities from its self-description
+func (i Info) Capabilities() Capabilities {
+	version, _ := i.FirmwareVersion()
+	caps := Capabilities{
+		CapabilityJWTAuth:  i.WebTokens || version.AtLeast(7, 0),
+		CapabilityMeters:   i.Device.IMeter,
+		CapabilityLiveData: version.AtLeast(7, 0),
+	}
+	for _, pkg := range i.Packages {
+		// Ensemble-capable firmware ships dedicated packages for the battery and system controller images
+		if strings.Contains(pkg.Name, "ensemble") || strings.HasPrefix(pkg.Name, "ess") {
+			caps[CapabilityEnsemble] = true
+		}
+	}
+	return caps
+}
+
+// Capabilities returns the capabilities of the Envoy, fetching /info the first time it is called. No token is
+// required.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	c.capMu.Lock()
+	defer c.capMu.Unlock()
+	if c.caps != nil {
+		return c.caps, nil
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.caps = info.Capabilities()
+	return c.caps, nil
+}
+
+// require fails fast with a *NotSupportedError matching ErrFirmwareUnsupported if the Envoy lacks *capability*
+func (c *Client) require(ctx context.Context, capability Capability) error {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	if !caps.Has(capability) {
+		return &NotSupportedError{Capability: capability, Err: ErrFirmwareUnsupported}
+	}
+	return nil
+}
+
+// getFeature fetches *path* like get, but reports a 404 or an empty payload as a *NotSupportedError for
+// *capability*, since that is how the Envoy answers for hardware that is not installed.
+func (c *Client) getFeature(ctx context.Context, capability Capability, path string, response interface{}) error {
+	var raw json.RawMessage
+	err := c.get(ctx, path, &raw)
+	if errors.Is(err, ErrNotFound) {
+		return &NotSupportedError{Capability: capability, Err: err}
+	}
+	if err != nil {
+		return err
+	}
+	switch string(bytes.TrimSpace(raw)) {
+	case "", "null", "{}", "[]":
+		return &NotSupportedError{Capability: capability}
+	}
+	if err := json.Unmarshal(raw, response); err != nil {
+		return err
+	}
+	c.localizeTimes(ctx, path, response)
+	return nil
+}
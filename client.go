@@ -1,116 +1,496 @@
 package envoy
 
 import (
-	"log"
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
+	"strings"
+	"sync"
+	"time"
 )
 
 var (
-	// ErrNotOK is returned if any of the Envoy APIs does not return a 200
+	// ErrNotOK is matched by the *APIError returned if any of the Envoy APIs does not return a 200
 	ErrNotOK = errors.New("server did not return 200")
+	// ErrAuthFailed is matched by the error returned if the Envoy still rejects a request after logging in again
+	ErrAuthFailed = errors.New("authentication failed")
 )
 
-// Client provides the API for interacting with the Envoy APIs
+// Client provides the API for interacting with the Envoy APIs.
+// A Client is safe for concurrent use by multiple goroutines once it has been created.
 type Client struct {
-	address string
-	client  *http.Client
-	token   string
-	proto   string
-	loggedin bool
+	address   string
+	client    *http.Client
+	proto     string
+	timeout   time.Duration
+	retry     RetryPolicy
+	reconnect ReconnectPolicy
+	tlsConfig *tls.Config
+	logger    *slog.Logger
+	headers   http.Header
+
+	limiter          *tokenBucket
+	endpointLimiters map[string]*tokenBucket
+	breaker          *circuitBreaker
+	cache            *responseCache
+	conditional      conditionalStore
+	stats            clientStats
+	// latestMu guards latest, the readings published by WithExpvar
+	latestMu sync.Mutex
+	latest   Readings
+
+	tokenProvider TokenProvider
+	refreshMargin time.Duration
+	// digest is set when the Client uses legacy digest authentication instead of a JWT
+	digest *digestTransport
+	// pin is set when the Envoy's certificate is pinned by fingerprint
+	pin         *certPin
+	middleware  []Middleware
+	confirmFunc ConfirmFunc
+	dryRun      bool
+	debug       *debugWriter
+	// expvars are the names the Client is published under by WithExpvar
+	expvars []string
+
+	// how often control operations poll for their outcome
+	gridProfilePoll time.Duration
+	pelPoll         time.Duration
+	provisionPoll   time.Duration
+	generatorPoll   time.Duration
+
+	// capMu guards caps, version and loc, which are detected once
+	capMu   sync.Mutex
+	caps    Capabilities
+	version *apiVersion
+	loc     *time.Location
+	// localTime is set when Timestamps are presented outside UTC, in presentLoc if set and in loc otherwise
+	localTime  bool
+	presentLoc *time.Location
+
+	// loginMu serializes Login so concurrent callers share a single session
+	loginMu sync.Mutex
+	// refreshMu serializes token refreshes so the TokenProvider is only consulted once
+	refreshMu sync.Mutex
+	// mu guards token, tokenExpiry and loggedin
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	loggedin    bool
 }
 
-// NewClient creates a new Client that will talk to an Envoy unit at *address*, creating its own http.Client underneath.
-func NewClient(address string, proto string) *Client {
-	insecureTr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// NewClient creates a new Client that will talk to an Envoy unit at *address*, configured by *opts*.
+// Unless WithHTTPClient is given, it creates its own http.Client underneath.
+func NewClient(address string, opts ...Option) *Client {
+	c := &Client{
+		address:       address,
+		proto:         "https",
+		logger:        slog.New(discardHandler{}),
+		timeout:       DefaultTimeout,
+		retry:         DefaultRetryPolicy,
+		reconnect:     DefaultReconnectPolicy,
+		refreshMargin: DefaultTokenRefreshMargin,
+
+		gridProfilePoll: DefaultGridProfilePollInterval,
+		pelPoll:         DefaultPowerExportLimitPollInterval,
+		provisionPoll:   DefaultProvisioningPollInterval,
+		generatorPoll:   DefaultGeneratorPollInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.tokenExpiry = tokenExpiry(c.token)
+	if c.client == nil {
+		c.client = c.newHTTPClient()
 	}
-	secureTr := &http.Transport{}
-	var tr *http.Transport
-	if proto == "https" {
-		tr = insecureTr
-	} else {
-		tr = secureTr
+	if c.digest != nil || c.debug != nil || len(c.middleware) > 0 {
+		// wrap a copy so a caller's http.Client is left untouched
+		hc := *c.client
+		hc.Transport = c.wrapTransport(hc.Transport)
+		c.client = &hc
 	}
-	client := &http.Client{Transport: tr}
+	// the jar is installed up front, since http.Client reads it without synchronization
+	if c.client.Jar == nil {
+		jar, _ := cookiejar.New(nil)
+		c.client.Jar = jar
+	}
+	c.publishExpvars()
+	return c
+}
 
-	return &Client{
-		address: address,
-		client:  client,
-		proto: proto,
+func (c *Client) newHTTPClient() *http.Client {
+	tr := &http.Transport{}
+	if c.proto == "https" {
+		config := c.tlsConfig
+		switch {
+		case c.pin != nil:
+			config = c.pin.tlsConfig(config)
+		case config == nil:
+			config = &tls.Config{InsecureSkipVerify: true}
+		}
+		tr.TLSClientConfig = config
 	}
+	return &http.Client{Transport: tr}
 }
 
-// NewClientWithHTTP creates a new Client that will talk to an Envoy unit at *address* using the provided http.Client.
-func NewClientWithHTTP(address string, proto string, client *http.Client) *Client {
-	return &Client{
-		address: address,
-		client:  client,
-		proto: proto,
+// withTimeout bounds *ctx* by the Client's timeout, unless the caller has already set a deadline of their own
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *Client) get(ctx context.Context, url string, response interface{}) error {
+	return c.call(ctx, http.MethodGet, url, nil, response)
 }
 
-func (c *Client) get(url string, response interface{}) error {
-	resp, err := c.client.Get(fmt.Sprintf("%s://%s%s", c.proto, c.address, url))
+// call sends *request*, if not nil, as JSON to *url* with *method*, and decodes the JSON response into *response*,
+// if not nil.
+func (c *Client) call(ctx context.Context, method, url string, request, response interface{}) error {
+	if err := c.exchange(ctx, method, url, request, response); err != nil {
+		return err
+	}
+	c.localizeTimes(ctx, url, response)
+	return nil
+}
+
+// exchange sends *request* and decodes the response into *response*, going through the cache for GETs
+func (c *Client) exchange(ctx context.Context, method, url string, request, response interface{}) error {
+	if method == http.MethodGet && !forceRefresh(ctx) {
+		if b, ok := c.cache.get(url); ok {
+			if response == nil {
+				return nil
+			}
+			return json.Unmarshal(b, response)
+		}
+	}
+	var body io.Reader
+	if request != nil {
+		b, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if method == http.MethodGet {
+		c.conditional.apply(req, url)
+	}
+	resp, err := c.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-
-	// try once to log in
-	if resp.StatusCode == http.StatusUnauthorized || !c.loggedin {
-		c.loggedin = false
-		c.Login()
-		return c.get(url, response)
+	if method != http.MethodGet {
+		// the state has changed, so nothing cached can be trusted, least of all by a readback
+		c.cache.clear()
 	}
 
+	if resp.StatusCode == http.StatusNoContent && method != http.MethodGet {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotModified && method == http.MethodGet {
+		// nothing has changed since the body the conditional request was based on
+		b, ok := c.conditional.body(url)
+		if !ok {
+			return newAPIError(resp)
+		}
+		return c.decodeBody(ctx, url, resp, b, response)
+	}
 	if resp.StatusCode != http.StatusOK {
-		return ErrNotOK
+		return newAPIError(resp)
+	}
+	if method == http.MethodGet && (c.cache != nil || validated(resp)) {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return c.decodeBody(ctx, url, resp, b, response)
+	}
+	if response == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		c.logger.DebugContext(ctx, "decoding response failed", "path", url, "error", err)
+		return err
+	}
+	return nil
+}
+
+// decodeBody decodes the fully read body *b* of a GET of *url* into *response*, then remembers it for the cache and
+// for conditional requests
+func (c *Client) decodeBody(ctx context.Context, url string, resp *http.Response, b []byte, response interface{}) error {
+	if response != nil {
+		if err := json.Unmarshal(b, response); err != nil {
+			c.logger.DebugContext(ctx, "decoding response failed", "path", url, "error", err)
+			return err
+		}
+	}
+	c.cache.put(url, b)
+	if resp.StatusCode == http.StatusOK {
+		c.conditional.put(url, resp, b)
+	}
+	return nil
+}
+
+// GetJSON fetches *path* from the Envoy and decodes the JSON response into *response*, with the same login, retry and
+// error handling as the typed methods. Use it for endpoints this package does not cover yet.
+func (c *Client) GetJSON(ctx context.Context, path string, response interface{}) error {
+	return c.get(ctx, path, response)
+}
+
+// Get fetches *path* from the Envoy and decodes the JSON response into a T, with the same login, retry and error
+// handling as the typed methods. It lets callers bring their own response types for niche endpoints.
+func Get[T any](ctx context.Context, c *Client, path string) (T, error) {
+	var response T
+	err := c.get(ctx, path, &response)
+	return response, err
+}
+
+// Do sends *req* to the Envoy, logging in first if necessary and once more if the Envoy reports the session expired.
+// A request URL without a host, such as one built from just a path, is sent to the Client's Envoy. As with
+// http.Client, responses with a non-200 status are not errors, except for a 401 that persists after logging in again,
+// and the caller must close the response body. The Client's timeout lasts until the body is closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := c.withTimeout(req.Context())
+	req = req.Clone(ctx)
+	if req.URL.Host == "" {
+		req.URL.Scheme = c.proto
+		req.URL.Host = c.address
+	}
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the context of a request once its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
 
-	return json.NewDecoder(resp.Body).Decode(response)
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
-// Inventory returns the list of parts installed in the system and registered with the Envoy unit
-func (c *Client) Inventory() ([]Inventory, error) {
-	var inventory []Inventory
-	err := c.get("/inventory.json?deleted=1", &inventory)
+// roundTrip sends *req*, handling authentication
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	jwt := c.digest == nil && c.api(ctx).jwt
+	if jwt {
+		if err := c.refreshToken(ctx, false); err != nil {
+			return nil, err
+		}
+		if !c.isLoggedIn() {
+			if err := c.Login(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resp, err := c.do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if !jwt {
+		// either the digest transport has already answered the challenge, so the credentials are wrong, or the
+		// firmware predates JWTs and wants digest credentials the Client was not given
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("%w: %w", ErrAuthFailed, newAPIError(resp))
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// the body has been consumed and cannot be sent again
+		return resp, nil
+	}
+
+	// the session may have expired, so re-authenticate exactly once before giving up
+	resp.Body.Close()
+	c.logger.DebugContext(ctx, "session rejected, logging in again", "path", req.URL.Path)
+	c.setLoggedIn(false)
+	if err := c.refreshToken(ctx, true); err != nil {
+		return nil, err
+	}
+	if err := c.Login(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	resp, err = c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		defer resp.Body.Close()
+		c.setLoggedIn(false)
+		return nil, fmt.Errorf("%w: %w", ErrAuthFailed, newAPIError(resp))
+	}
+	return resp, nil
+}
+
+// InventoryOptions selects what Inventory lists
+type InventoryOptions struct {
+	// IncludeDeleted lists devices that have been retired or replaced, marked Deleted, alongside the active ones
+	IncludeDeleted bool
+}
+
+// Inventory returns the parts installed in the system and registered with the Envoy unit, by device class
+func (c *Client) Inventory(ctx context.Context, opts InventoryOptions) (Inventory, error) {
+	var inventory Inventory
+	path := inventoryPath
+	if opts.IncludeDeleted {
+		path += "?deleted=1"
+	}
+	err := c.get(ctx, path, &inventory)
 	return inventory, err
 }
 
-// Production returns the current data for Production and Consumption sensors, if equipped.
-func (c *Client) Production() (Production, error) {
-	var production Production
-	err := c.get("/production.json?details=1", &production)
+// Production returns the current data for Production and Consumption sensors, if equipped. Firmware before 3.9,
+// which predates production.json, only reports the inverters' current and lifetime production.
+func (c *Client) Production(ctx context.Context) (Production, error) {
+	api := c.api(ctx)
+	var raw json.RawMessage
+	if err := c.get(ctx, api.productionPath, &raw); err != nil {
+		return Production{}, err
+	}
+	production, err := api.decodeProduction(raw)
+	if err == nil {
+		c.localizeTimes(ctx, api.productionPath, &production)
+		r := readingsOf(production)
+		r.Time = time.Now()
+		c.recordLatest(r, false)
+	}
 	return production, err
 }
 
+func (c *Client) isLoggedIn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loggedin
+}
+
+func (c *Client) setLoggedIn(loggedin bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loggedin = loggedin
+}
+
+// SetToken replaces the JWT used to authenticate against the Envoy. The next request will log in again.
 func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.token = token
+	c.tokenExpiry = tokenExpiry(token)
+	c.loggedin = false
 }
-func (c *Client) Login() error {
-	if c.loggedin && c.client.Jar != nil {
-		log.Printf("Already logged in, skipping")
+
+// Login validates the configured token against the Envoy and establishes a session cookie.
+// Concurrent calls are serialized, and calls made while a session is already established return immediately.
+// If a TokenProvider is configured, it is consulted first when the current token is missing or about to expire.
+// With digest authentication Login does nothing, since credentials are sent with every request.
+func (c *Client) Login(ctx context.Context) error {
+	if c.digest != nil {
+		return nil
+	}
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	if err := c.refreshToken(ctx, false); err != nil {
+		return err
+	}
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	c.mu.Lock()
+	loggedin, token := c.loggedin, c.token
+	c.mu.Unlock()
+	if loggedin {
+		c.logger.DebugContext(ctx, "already logged in, skipping")
 		return nil
 	}
 	authURI := fmt.Sprintf("%s://%s/auth/check_jwt", c.proto, c.address)
-	req, err := http.NewRequest("GET", authURI, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURI, nil)
 	if err != nil {
 		return err
 	}
-	if c.client.Jar == nil {
-		jar, err := cookiejar.New(nil)
-		if err != nil {
-			return err
-		}
-		c.client.Jar = jar
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.logger.DebugContext(ctx, "login rejected", "status", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &AuthError{StatusCode: resp.StatusCode, Message: stripTags(string(body))}
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-	_, err = c.client.Do(req)
-	c.loggedin = true
+	c.logger.DebugContext(ctx, "logged in")
+	c.setLoggedIn(true)
 	return nil
 }
+
+// AuthError is returned by Login when the Envoy rejects the token. It matches ErrAuthFailed with errors.Is.
+type AuthError struct {
+	// StatusCode is the HTTP status returned by the Envoy
+	StatusCode int
+	// Message is the text of the Envoy's response, if any
+	Message string
+}
+
+func (e *AuthError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("authentication failed: status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("authentication failed: status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether target is ErrAuthFailed, or ErrUnauthorized if the Envoy answered with 401
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuthFailed || target == ErrUnauthorized && e.StatusCode == http.StatusUnauthorized
+}
+
+// maxErrorBody caps how much of an error response is read into an error message
+const maxErrorBody = 4096
+
+// stripTags reduces the small HTML documents the Envoy returns for errors to their text
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+			b.WriteRune(' ')
+		case r == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,126 @@
+package envoy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testProduction = `{"production":[{"type":"inverters","activeCount":1,"wNow":250}]}`
+
+func TestConcurrentLogin(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON("/production.json", testProduction)
+	c := f.client()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- c.Login(ctx)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := c.Production(ctx)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := f.loginCount(); n != 1 {
+		t.Errorf("logged in %d times, want 1", n)
+	}
+}
+
+func TestConcurrentRelogin(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON("/production.json", testProduction)
+	c := f.client()
+	ctx := context.Background()
+	if _, err := c.Production(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.expire()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%5 == 0 {
+				// replacing the token mid-flight must not race with the sessions being re-established
+				c.SetToken(testToken)
+			}
+			p, err := c.Production(ctx)
+			if err == nil && len(p.Production) != 1 {
+				t.Errorf("got %d production readings, want 1", len(p.Production))
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := f.loginCount(); n < 2 {
+		t.Errorf("logged in %d times, want a second login after the session expired", n)
+	}
+}
+
+func TestPersistentUnauthorized(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON("/production.json", testProduction)
+	f.rejectAll = true
+	c := f.client()
+
+	_, err := c.Production(context.Background())
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+	if n := f.loginCount(); n != 2 {
+		t.Errorf("logged in %d times, want the initial login and exactly one more", n)
+	}
+	if n := f.requestCount("/production.json"); n != 2 {
+		t.Errorf("sent %d requests, want 2", n)
+	}
+}
+
+func TestGzipResponse(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handle("/gzipped", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("request did not accept gzip: %q", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`{"ok":true}`))
+		zw.Close()
+	})
+	var debug bytes.Buffer
+	c := f.client(WithDebug(&debug))
+	var v struct{ OK bool }
+	if err := c.GetJSON(context.Background(), "/gzipped", &v); err != nil || !v.OK {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+	if !strings.Contains(debug.String(), `{"ok":true}`) {
+		t.Errorf("debug output does not show the decoded body:\n%s", debug.String())
+	}
+}
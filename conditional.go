@@ -0,0 +1,67 @@
+package envoy
+
+import (
+	"net/http"
+	"sync"
+)
+
+// validatedBody is a response body along with the validators the Envoy sent with it
+type validatedBody struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+// conditionalStore remembers the responses that carried an ETag or Last-Modified, so later requests for them can be
+// made conditional and a 304 Not Modified answered from memory. The zero value is ready to use.
+type conditionalStore struct {
+	mu      sync.Mutex
+	entries map[string]validatedBody
+}
+
+// apply adds If-None-Match and If-Modified-Since to *req* if a validated response for *url* is known
+func (s *conditionalStore) apply(req *http.Request, url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.entries[url]
+	if !ok {
+		return
+	}
+	if v.etag != "" {
+		req.Header.Set("If-None-Match", v.etag)
+	}
+	if v.lastModified != "" {
+		req.Header.Set("If-Modified-Since", v.lastModified)
+	}
+}
+
+// body returns the remembered body for *url*
+func (s *conditionalStore) body(url string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.entries[url]
+	return v.body, ok
+}
+
+// put remembers *body* for *url* if *resp* carried validators, and forgets any earlier body otherwise
+func (s *conditionalStore) put(url string, resp *http.Response, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !validated(resp) {
+		delete(s.entries, url)
+		return
+	}
+	if s.entries == nil {
+		s.entries = make(map[string]validatedBody)
+	}
+	s.entries[url] = validatedBody{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		body:         body,
+	}
+}
+
+// validated reports whether *resp* carries an ETag or Last-Modified
+func validated(resp *http.Response) bool {
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
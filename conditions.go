@@ -0,0 +1,138 @@
+package envoy
+
+import "strings"
+
+// Condition is a status flag the Envoy reports for a device, such as envoy.global.ok
+type Condition string
+
+// Known device conditions
+const (
+	ConditionOK Condition = "envoy.global.ok"
+
+	// microinverter controller conditions
+	ConditionPCUAlertActive         Condition = "envoy.cond_flags.pcu_ctrl.alertactive"
+	ConditionPCUAltPowerForced      Condition = "envoy.cond_flags.pcu_ctrl.altpwrforced"
+	ConditionPCUBridgeFault         Condition = "envoy.cond_flags.pcu_ctrl.bridgefault"
+	ConditionPCUClockError          Condition = "envoy.cond_flags.pcu_ctrl.clockerror"
+	ConditionPCUCommandedReset      Condition = "envoy.cond_flags.pcu_ctrl.commandedreset"
+	ConditionPCUCriticalTemperature Condition = "envoy.cond_flags.pcu_ctrl.critical-temperature"
+	ConditionPCUDCPowerLow          Condition = "envoy.cond_flags.pcu_ctrl.dc-pwr-low"
+	ConditionPCUGFITripped          Condition = "envoy.cond_flags.pcu_ctrl.gfitripped"
+	ConditionPCUUplinkProblem       Condition = "envoy.cond_flags.pcu_ctrl.iuplinkproblem"
+	ConditionPCUOverTemperature     Condition = "envoy.cond_flags.pcu_ctrl.overtemperature"
+	ConditionPCUPowerOnReset        Condition = "envoy.cond_flags.pcu_ctrl.poweronreset"
+	ConditionPCUPowerOffByCommand   Condition = "envoy.cond_flags.pcu_ctrl.pwrgenoffbycmd"
+	ConditionPCURunningOnAC         Condition = "envoy.cond_flags.pcu_ctrl.runningonac"
+	ConditionPCUUnexpectedReset     Condition = "envoy.cond_flags.pcu_ctrl.unexpectedreset"
+	ConditionPCUWatchdogReset       Condition = "envoy.cond_flags.pcu_ctrl.watchdogreset"
+
+	// microinverter channel conditions
+	ConditionChanACMonitorError   Condition = "envoy.cond_flags.pcu_chan.acMonitorError"
+	ConditionChanACFrequencyHigh  Condition = "envoy.cond_flags.pcu_chan.acfrequencyhigh"
+	ConditionChanACFrequencyLow   Condition = "envoy.cond_flags.pcu_chan.acfrequencylow"
+	ConditionChanACVoltageHigh    Condition = "envoy.cond_flags.pcu_chan.acvoltagehigh"
+	ConditionChanACVoltageLow     Condition = "envoy.cond_flags.pcu_chan.acvoltagelow"
+	ConditionChanDCVoltageTooHigh Condition = "envoy.cond_flags.pcu_chan.dcvoltagetoohigh"
+	ConditionChanDCVoltageTooLow  Condition = "envoy.cond_flags.pcu_chan.dcvoltagetoolow"
+	ConditionChanGridGone         Condition = "envoy.cond_flags.pcu_chan.gridgone"
+	ConditionChanGridInstability  Condition = "envoy.cond_flags.pcu_chan.gridinstability"
+	ConditionChanSkippedCycles    Condition = "envoy.cond_flags.pcu_chan.skippedcycles"
+
+	// device discovery and monitoring conditions
+	ConditionDiscovering  Condition = "envoy.cond_flags.obs_strs.discovering"
+	ConditionFailure      Condition = "envoy.cond_flags.obs_strs.failure"
+	ConditionFlashError   Condition = "envoy.cond_flags.obs_strs.flasherror"
+	ConditionNotMonitored Condition = "envoy.cond_flags.obs_strs.notmonitored"
+	ConditionVerifying    Condition = "envoy.cond_flags.obs_strs.verifing"
+
+	// revenue-grade meter conditions
+	ConditionMeterCheck        Condition = "envoy.cond_flags.rgm_chan.check_meter"
+	ConditionMeterPowerQuality Condition = "envoy.cond_flags.rgm_chan.power_quality"
+)
+
+var conditionSeverity = map[Condition]Severity{
+	ConditionOK: SeverityInfo,
+
+	ConditionPCUAlertActive:         SeverityWarning,
+	ConditionPCUAltPowerForced:      SeverityWarning,
+	ConditionPCUBridgeFault:         SeverityError,
+	ConditionPCUClockError:          SeverityError,
+	ConditionPCUCommandedReset:      SeverityInfo,
+	ConditionPCUCriticalTemperature: SeverityError,
+	ConditionPCUDCPowerLow:          SeverityInfo,
+	ConditionPCUGFITripped:          SeverityError,
+	ConditionPCUUplinkProblem:       SeverityError,
+	ConditionPCUOverTemperature:     SeverityWarning,
+	ConditionPCUPowerOnReset:        SeverityInfo,
+	ConditionPCUPowerOffByCommand:   SeverityInfo,
+	ConditionPCURunningOnAC:         SeverityInfo,
+	ConditionPCUUnexpectedReset:     SeverityError,
+	ConditionPCUWatchdogReset:       SeverityError,
+
+	ConditionChanACMonitorError:   SeverityError,
+	ConditionChanACFrequencyHigh:  SeverityWarning,
+	ConditionChanACFrequencyLow:   SeverityWarning,
+	ConditionChanACVoltageHigh:    SeverityWarning,
+	ConditionChanACVoltageLow:     SeverityWarning,
+	ConditionChanDCVoltageTooHigh: SeverityError,
+	ConditionChanDCVoltageTooLow:  SeverityWarning,
+	ConditionChanGridGone:         SeverityWarning,
+	ConditionChanGridInstability:  SeverityWarning,
+	ConditionChanSkippedCycles:    SeverityWarning,
+
+	ConditionDiscovering:  SeverityInfo,
+	ConditionFailure:      SeverityError,
+	ConditionFlashError:   SeverityError,
+	ConditionNotMonitored: SeverityWarning,
+	ConditionVerifying:    SeverityInfo,
+
+	ConditionMeterCheck:        SeverityError,
+	ConditionMeterPowerQuality: SeverityWarning,
+}
+
+// Severity classifies the condition. Unknown conditions are errors if their name mentions an error, fault or
+// failure, and warnings otherwise.
+func (c Condition) Severity() Severity {
+	if s, ok := conditionSeverity[c]; ok {
+		return s
+	}
+	name := strings.ToLower(string(c))
+	if strings.Contains(name, "error") || strings.Contains(name, "fault") || strings.Contains(name, "fail") {
+		return SeverityError
+	}
+	return SeverityWarning
+}
+
+// IsError reports whether the condition is a fault worth alerting on
+func (c Condition) IsError() bool {
+	return c.Severity() == SeverityError
+}
+
+// Conditions is a device's list of conditions
+type Conditions []Condition
+
+// Severity returns the most severe of the conditions, or SeverityInfo if there are none
+func (cs Conditions) Severity() Severity {
+	s := SeverityInfo
+	for _, c := range cs {
+		if cond := c.Severity(); cond > s {
+			s = cond
+		}
+	}
+	return s
+}
+
+// IsError reports whether any of the conditions is an error
+func (cs Conditions) IsError() bool {
+	return cs.Severity() == SeverityError
+}
+
+// Has reports whether *c* is one of the conditions
+func (cs Conditions) Has(c Condition) bool {
+	for _, x := range cs {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
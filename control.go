@@ -0,0 +1,48 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotConfirmed is returned when the ConfirmFunc declines an action that changes the state of the system
+	ErrNotConfirmed = errors.New("action not confirmed")
+	// ErrNotApplied is returned when reading a setting back after changing it shows the Envoy did not apply it
+	ErrNotApplied = errors.New("setting not applied")
+	// ErrDryRun is returned instead of performing an action that changes the state of the system in dry-run mode
+	ErrDryRun = errors.New("dry run")
+)
+
+// ConfirmFunc is consulted before the Client performs an action that changes the state of the system, such as
+// switching a relay. *action* describes it in plain words. Returning false cancels the action.
+type ConfirmFunc func(ctx context.Context, action string) bool
+
+// WithConfirm makes the Client ask *confirm* before every action that changes the state of the system, as a safety
+// net for automations. By default actions are performed without asking.
+func WithConfirm(confirm ConfirmFunc) Option {
+	return func(c *Client) {
+		c.confirmFunc = confirm
+	}
+}
+
+// WithDryRun makes the Client log actions that change the state of the system and return ErrDryRun instead of
+// performing them, after the ConfirmFunc, if any, has approved them.
+func WithDryRun() Option {
+	return func(c *Client) {
+		c.dryRun = true
+	}
+}
+
+// confirm asks the ConfirmFunc, if any, whether *action* may proceed, and stops it in dry-run mode
+func (c *Client) confirm(ctx context.Context, action string) error {
+	if c.confirmFunc != nil && !c.confirmFunc(ctx, action) {
+		c.logger.DebugContext(ctx, "action declined", "action", action)
+		return ErrNotConfirmed
+	}
+	if c.dryRun {
+		c.logger.InfoContext(ctx, "dry run, not performing action", "action", action)
+		return ErrDryRun
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package envoy
+
+import "sync"
+
+// EnergyCounterState is the state of an EnergyCounter, to persist it across process restarts
+type EnergyCounterState struct {
+	// Last is the last raw counter value observed
+	Last WattHours `json:"last"`
+	// Total is the monotonic total
+	Total WattHours `json:"total"`
+	// Resets is how many resets and rollovers have been detected
+	Resets int `json:"resets"`
+	// Started is set once a value has been observed
+	Started bool `json:"started"`
+}
+
+// EnergyCounter turns a lifetime Wh counter from the Envoy, such as ProductionData.WhLifetime, into a monotonic
+// total. The counters occasionally reset after firmware updates or meter replacements, and the energy reported
+// after a reset is added on top of what came before, so the total suits billing and Prometheus counters. The zero
+// value is ready to use.
+type EnergyCounter struct {
+	// Tolerance is how far the counter may go backwards without being taken for a reset, to absorb meter jitter
+	Tolerance WattHours
+	// RolloverAt is the value at which the counter wraps to zero, if it does
+	RolloverAt WattHours
+
+	mu    sync.Mutex
+	state EnergyCounterState
+}
+
+// Observe records the raw counter value *v* and returns the monotonic total. The first value observed starts the
+// total, so it matches the counter until the counter resets.
+func (c *EnergyCounter) Observe(v WattHours) WattHours {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &c.state
+	switch {
+	case !s.Started:
+		s.Total, s.Started = v, true
+	case v >= s.Last:
+		s.Total += v - s.Last
+	case s.Last-v <= c.Tolerance:
+		// jitter: hold the last value so the dip is not counted again when the counter recovers
+		return s.Total
+	case c.RolloverAt > 0 && s.Last >= c.RolloverAt*9/10:
+		s.Total += c.RolloverAt - s.Last + v
+		s.Resets++
+	default:
+		// the counter restarted from zero, so everything it shows now is new
+		s.Total += v
+		s.Resets++
+	}
+	s.Last = v
+	return s.Total
+}
+
+// Total returns the monotonic total
+func (c *EnergyCounter) Total() WattHours {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Total
+}
+
+// State returns the state of the counter, e.g. to save it
+func (c *EnergyCounter) State() EnergyCounterState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Restore replaces the state of the counter, e.g. with one saved before a restart
+func (c *EnergyCounter) Restore(state EnergyCounterState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.state = state
+}
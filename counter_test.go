@@ -0,0 +1,48 @@
+package envoy
+
+import "testing"
+
+func TestEnergyCounter(t *testing.T) {
+	c := EnergyCounter{Tolerance: 5, RolloverAt: 1000}
+	for i, step := range []struct {
+		raw, want WattHours
+	}{
+		{500, 500},
+		{600, 600},
+		// jitter within the tolerance is held, and not counted again on recovery
+		{597, 600},
+		{610, 610},
+		// a reset to zero adds everything shown since
+		{0, 610},
+		{40, 650},
+		{950, 1560},
+		// near RolloverAt a drop is a wrap, not a reset
+		{20, 1630},
+	} {
+		if got := c.Observe(step.raw); got != step.want {
+			t.Errorf("step %d: observed %v, got total %v, want %v", i, step.raw, got, step.want)
+		}
+	}
+	if s := c.State(); s.Resets != 2 || s.Last != 20 || s.Total != 1630 {
+		t.Errorf("got state %+v, want 2 resets ending at 20 with total 1630", s)
+	}
+}
+
+func TestEnergyCounterRestore(t *testing.T) {
+	var saved EnergyCounter
+	saved.Observe(1000)
+	saved.Observe(1200)
+
+	var c EnergyCounter
+	c.Restore(saved.State())
+	if got := c.Total(); got != 1200 {
+		t.Errorf("got total %v after restoring, want 1200", got)
+	}
+	// the restored counter continues from the saved value rather than starting over
+	if got := c.Observe(1250); got != 1250 {
+		t.Errorf("got total %v, want 1250", got)
+	}
+	if got := c.Observe(100); got != 1350 {
+		t.Errorf("got total %v after a reset, want 1350", got)
+	}
+}
@@ -0,0 +1,193 @@
+package envoy
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// enlightenTimeLayout is how Enlighten's CSV export formats its Date/Time column
+const enlightenTimeLayout = "01/02/2006 15:04"
+
+// CSVColumn is a column written by a CSVExporter
+type CSVColumn struct {
+	Header string
+	// Value formats the column of *s*, whose Time is in the exporter's location
+	Value func(s Sample) string
+}
+
+// Columns of a CSVExporter. Headers follow Enlighten's CSV export, and power columns are empty when the sample has
+// no such reading.
+var (
+	CSVColumnTime        = CSVColumn{"Date/Time", func(s Sample) string { return s.Time.Format(enlightenTimeLayout) }}
+	CSVColumnSource      = CSVColumn{"Source", func(s Sample) string { return s.Source }}
+	CSVColumnSerial      = CSVColumn{"Serial Number", func(s Sample) string { return s.Serial }}
+	CSVColumnProduction  = CSVColumn{"Power Produced (W)", func(s Sample) string { return csvNumber(float64(s.Production)) }}
+	CSVColumnConsumption = CSVColumn{"Power Consumed (W)", func(s Sample) string {
+		return csvOptional(s.HasConsumption, float64(s.Consumption))
+	}}
+	CSVColumnNet = CSVColumn{"Imported from Grid (W)", func(s Sample) string {
+		return csvOptional(s.HasConsumption, float64(s.Net))
+	}}
+	CSVColumnBattery = CSVColumn{"Battery Discharge (W)", func(s Sample) string {
+		return csvOptional(s.HasBattery, float64(s.Battery))
+	}}
+	CSVColumnSOC = CSVColumn{"Battery Charge (%)", func(s Sample) string { return csvOptional(s.HasBattery, s.SOC) }}
+)
+
+// DefaultCSVColumns are the columns of a CSVExporter unless it is given others
+var DefaultCSVColumns = []CSVColumn{
+	CSVColumnTime, CSVColumnSource, CSVColumnSerial, CSVColumnProduction, CSVColumnConsumption, CSVColumnNet,
+	CSVColumnBattery, CSVColumnSOC,
+}
+
+func csvNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func csvOptional(ok bool, v float64) string {
+	if !ok {
+		return ""
+	}
+	return csvNumber(v)
+}
+
+// CSVExporter is a Sink writing the Samples of each Update as CSV rows, to one file per local day or to a single
+// writer. Call Close when done. The zero value is not usable; set Dir or Out.
+type CSVExporter struct {
+	// Dir is where the daily files are written, named e.g. envoy-2024-06-01.csv. Rows are appended to a file that
+	// already exists, so a restart continues the day's file.
+	Dir string
+	// Prefix starts the file names, "envoy" if empty
+	Prefix string
+	// Out, if set, receives all rows instead of daily files
+	Out io.Writer
+	// Columns are the columns written, DefaultCSVColumns if nil
+	Columns []CSVColumn
+	// Location is where times are formatted and days begin, typically the Envoy's from Client.Location; UTC if nil
+	Location *time.Location
+
+	mu     sync.Mutex
+	w      *csv.Writer
+	file   *os.File
+	day    string
+	header bool
+}
+
+// Write writes the Samples of *u*
+func (e *CSVExporter) Write(ctx context.Context, u Update) error {
+	for _, s := range Samples(u) {
+		if err := e.WriteSample(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSample writes *s* as one row, starting a new file when its day differs from the previous row's
+func (e *CSVExporter) WriteSample(s Sample) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	s.Time = s.Time.In(loc)
+	if err := e.open(s.Time.Format(time.DateOnly)); err != nil {
+		return err
+	}
+	columns := e.Columns
+	if columns == nil {
+		columns = DefaultCSVColumns
+	}
+	row := make([]string, len(columns))
+	if !e.header {
+		for i, c := range columns {
+			row[i] = c.Header
+		}
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+		e.header = true
+	}
+	for i, c := range columns {
+		row[i] = c.Value(s)
+	}
+	if err := e.w.Write(row); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// open makes the file of *day* current; the caller holds mu
+func (e *CSVExporter) open(day string) error {
+	if e.Out != nil {
+		if e.w == nil {
+			e.w = csv.NewWriter(e.Out)
+		}
+		return nil
+	}
+	if e.file != nil && day == e.day {
+		return nil
+	}
+	if err := e.closeFile(); err != nil {
+		return err
+	}
+	prefix := e.Prefix
+	if prefix == "" {
+		prefix = "envoy"
+	}
+	f, err := os.OpenFile(filepath.Join(e.Dir, prefix+"-"+day+".csv"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	e.file, e.w, e.day, e.header = f, csv.NewWriter(f), day, info.Size() > 0
+	return nil
+}
+
+func (e *CSVExporter) closeFile() error {
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file, e.w = nil, nil
+	return err
+}
+
+// Close closes the current file. Out is not closed.
+func (e *CSVExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closeFile()
+}
+
+// WriteEnlightenCSV writes *totals*, e.g. from Aggregator.Daily, to *w* in the format of Enlighten's energy CSV
+// export, so the Envoy's figures can be compared with Enlighten's line by line
+func WriteEnlightenCSV(w io.Writer, totals []EnergyTotals) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Date/Time", "Energy Produced (Wh)", "Energy Consumed (Wh)", "Exported to Grid (Wh)",
+		"Imported from Grid (Wh)"})
+	for _, t := range totals {
+		cw.Write([]string{
+			t.Start.Format(enlightenTimeLayout),
+			strconv.FormatFloat(math.Round(float64(t.Production)), 'f', 0, 64),
+			strconv.FormatFloat(math.Round(float64(t.Consumption)), 'f', 0, 64),
+			strconv.FormatFloat(math.Round(float64(t.Export)), 'f', 0, 64),
+			strconv.FormatFloat(math.Round(float64(t.Import)), 'f', 0, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
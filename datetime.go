@@ -0,0 +1,66 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// dateTimePath serves the clock and timezone configuration
+const dateTimePath = "/admin/lib/date_time_display.json"
+
+// DateTime is the clock and timezone configuration from /admin/lib/date_time_display.json
+type DateTime struct {
+	// Timezone is an IANA zone name such as US/Pacific
+	Timezone string `json:"tz,omitempty"`
+	// Date and Time are the Envoy's local wall clock as displayed, e.g. 2024-03-01 and 13:04
+	Date   string `json:"date,omitempty"`
+	Time   string `json:"time,omitempty"`
+	Locale string `json:"locale,omitempty"`
+	// NTPEnabled reports whether the clock is set from NTP; if not, timestamps may drift
+	NTPEnabled bool   `json:"ntp_enabled,omitempty"`
+	NTPServer  string `json:"ntp_server,omitempty"`
+	// Synced reports whether the clock has synchronized since boot
+	Synced bool `json:"synced,omitempty"`
+}
+
+// Location returns the configured timezone, or UTC if none is configured
+func (d DateTime) Location() (*time.Location, error) {
+	if d.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone %q: %w", d.Timezone, err)
+	}
+	return loc, nil
+}
+
+// DateTime returns the Envoy's clock and timezone settings
+func (c *Client) DateTime(ctx context.Context) (DateTime, error) {
+	var dt DateTime
+	err := c.get(ctx, dateTimePath, &dt)
+	return dt, err
+}
+
+// Location returns the Envoy's configured timezone, for localizing the epoch timestamps it reports. It is fetched
+// once and cached for the life of the Client.
+func (c *Client) Location(ctx context.Context) (*time.Location, error) {
+	c.capMu.Lock()
+	loc := c.loc
+	c.capMu.Unlock()
+	if loc != nil {
+		return loc, nil
+	}
+	dt, err := c.DateTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if loc, err = dt.Location(); err != nil {
+		return nil, err
+	}
+	c.capMu.Lock()
+	c.loc = loc
+	c.capMu.Unlock()
+	return loc, nil
+}
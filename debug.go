@@ -0,0 +1,155 @@
+package envoy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values of headers that carry credentials in debug dumps
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never written to debug dumps
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// sensitiveFields are the form and JSON fields of request bodies that are redacted in debug dumps, e.g. the Wi-Fi
+// passphrase JoinWiFi sends as key
+var sensitiveFields = map[string]bool{
+	"password": true, "user[password]": true, "key": true, "passphrase": true, "token": true, "session_id": true,
+}
+
+// WithDebug writes every request and response exchanged with the Envoy to *w*, with credentials, cookies and secret
+// request fields such as passwords redacted, which is useful for reporting differences between firmware versions.
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debug = &debugWriter{w: w}
+	}
+}
+
+type debugWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (d *debugWriter) write(b []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.w.Write(b)
+	d.w.Write([]byte("\n\n"))
+}
+
+// transport returns a RoundTripper that dumps each exchange before passing it to *next*
+func (d *debugWriter) transport(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var body []byte
+		if req.Body != nil {
+			var err error
+			if body, err = io.ReadAll(req.Body); err != nil {
+				return nil, err
+			}
+			req.Body.Close()
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		dumpReq := req.Clone(req.Context())
+		dumpReq.Header = redactHeaders(req.Header)
+		if body != nil {
+			redactedBody := redactBody(req.Header.Get("Content-Type"), body)
+			dumpReq.Body = io.NopCloser(bytes.NewReader(redactedBody))
+			dumpReq.ContentLength = int64(len(redactedBody))
+		}
+		if dump, err := httputil.DumpRequestOut(dumpReq, true); err == nil {
+			d.write(dump)
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			d.write([]byte(fmt.Sprintf("%s %s: %v", req.Method, req.URL, err)))
+			return resp, err
+		}
+		header := resp.Header
+		resp.Header = redactHeaders(header)
+		// DumpResponse replaces the body with an equivalent one it has buffered, which would never finish for an
+		// event stream, so only the headers of those are dumped
+		dump, dumpErr := httputil.DumpResponse(resp, !isEventStream(req, resp))
+		resp.Header = header
+		if dumpErr != nil {
+			resp.Body.Close()
+			return nil, dumpErr
+		}
+		d.write(dump)
+		return resp, nil
+	})
+}
+
+// isEventStream reports whether *resp* is, or *req* asked for, a server-sent event stream
+func isEventStream(req *http.Request, resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), eventStream) ||
+		strings.Contains(req.Header.Get("Accept"), eventStream)
+}
+
+// redactBody returns *body* with the values of sensitiveFields replaced, if it is a form or JSON. A body that cannot
+// be parsed as its *contentType* says is left out entirely, since it may still hold a secret.
+func redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte(redacted)
+		}
+		for name := range form {
+			if sensitiveFields[name] {
+				form.Set(name, redacted)
+			}
+		}
+		return []byte(form.Encode())
+	case mediaType == "application/json", len(bytes.TrimSpace(body)) > 0 && json.Valid(body):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return []byte(redacted)
+		}
+		b, err := json.Marshal(redactJSON(v))
+		if err != nil {
+			return []byte(redacted)
+		}
+		return b
+	}
+	return body
+}
+
+// redactJSON replaces the values of sensitiveFields anywhere within the decoded JSON *v*
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if sensitiveFields[k] {
+				v[k] = redacted
+			} else {
+				v[k] = redactJSON(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = redactJSON(e)
+		}
+	}
+	return v
+}
+
+func redactHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := h[name]; ok {
+			h.Set(name, redacted)
+		}
+	}
+	return h
+}
@@ -0,0 +1,89 @@
+package envoy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that can be written by the debug transport while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDebugStream(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handle("/stream/meter", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"production\":{\"ph-a\":{\"p\":250}}}\n\n")
+		w.(http.Flusher).Flush()
+		// hold the stream open as the Envoy does
+		<-r.Context().Done()
+	})
+	var debug syncBuffer
+	c := f.client(WithDebug(&debug))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	samples, err := c.StreamMeter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sample := <-samples:
+		if a := sample.Production.PhaseA; a == nil || a.P != 250 {
+			t.Errorf("got phase A %+v, want 250 W", a)
+		}
+	case <-ctx.Done():
+		t.Fatal("no sample received with debugging enabled")
+	}
+	if out := debug.String(); !strings.Contains(out, "text/event-stream") {
+		t.Errorf("debug output does not show the stream's headers:\n%s", out)
+	}
+	cancel()
+	for range samples {
+	}
+}
+
+func TestDebugRedactsBody(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON(wirelessPath, `{}`)
+	var debug syncBuffer
+	c := f.client(WithDebug(&debug), WithConfirm(func(ctx context.Context, action string) bool { return true }))
+
+	if err := c.JoinWiFi(context.Background(), "home", "correct horse battery staple", "wpa2-psk"); err != nil {
+		t.Fatal(err)
+	}
+	out := debug.String()
+	if strings.Contains(out, "correct horse") {
+		t.Errorf("debug output shows the passphrase:\n%s", out)
+	}
+	if !strings.Contains(out, `"ssid":"home"`) || !strings.Contains(out, `"key":"REDACTED"`) {
+		t.Errorf("debug output does not show the redacted body:\n%s", out)
+	}
+}
+
+func TestRedactForm(t *testing.T) {
+	got := string(redactBody("application/x-www-form-urlencoded", []byte("user%5Bemail%5D=a%40b.c&user%5Bpassword%5D=hunter2")))
+	if strings.Contains(got, "hunter2") || !strings.Contains(got, "a%40b.c") {
+		t.Errorf("got %s, want the email kept and the password redacted", got)
+	}
+}
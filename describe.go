@@ -0,0 +1,82 @@
+package envoy
+
+import "strings"
+
+// descriptions maps condition flags, and event types in lower case, to a description for humans
+var descriptions = map[string]string{
+	string(ConditionOK): "Normal operation",
+
+	string(ConditionPCUAlertActive):         "The microinverter has an active alert",
+	string(ConditionPCUAltPowerForced):      "The microinverter has been forced to run from alternate power",
+	string(ConditionPCUBridgeFault):         "The microinverter's output bridge has faulted; it may need replacing",
+	string(ConditionPCUClockError):          "The microinverter's clock has failed",
+	string(ConditionPCUCommandedReset):      "The microinverter was reset by command",
+	string(ConditionPCUCriticalTemperature): "The microinverter reached a critical temperature and stopped producing",
+	string(ConditionPCUDCPowerLow):          "The microinverter's DC input power is too low, as at night or when the module is shaded or disconnected",
+	string(ConditionPCUGFITripped):          "The ground fault interrupter has tripped; the fault must be located and cleared",
+	string(ConditionPCUUplinkProblem):       "The microinverter cannot communicate with the Envoy",
+	string(ConditionPCUOverTemperature):     "The microinverter is too hot and has reduced its output",
+	string(ConditionPCUPowerOnReset):        "The microinverter restarted after losing power",
+	string(ConditionPCUPowerOffByCommand):   "Production was turned off by command",
+	string(ConditionPCURunningOnAC):         "The microinverter is powered from the AC side",
+	string(ConditionPCUUnexpectedReset):     "The microinverter reset unexpectedly",
+	string(ConditionPCUWatchdogReset):       "The microinverter was reset by its watchdog",
+
+	string(ConditionChanACMonitorError):   "The microinverter's AC monitor has failed a self-test",
+	string(ConditionChanACFrequencyHigh):  "The grid frequency is above the limit of the grid profile",
+	string(ConditionChanACFrequencyLow):   "The grid frequency is below the limit of the grid profile",
+	string(ConditionChanACVoltageHigh):    "The grid voltage is above the limit of the grid profile",
+	string(ConditionChanACVoltageLow):     "The grid voltage is below the limit of the grid profile",
+	string(ConditionChanDCVoltageTooHigh): "The DC input voltage is too high for the microinverter",
+	string(ConditionChanDCVoltageTooLow):  "The DC input voltage is too low for the microinverter to produce",
+	string(ConditionChanGridGone):         "The grid is down, so the microinverter has stopped producing",
+	string(ConditionChanGridInstability):  "The grid voltage or frequency is unstable, so the microinverter has stopped producing",
+	string(ConditionChanSkippedCycles):    "The microinverter skipped AC cycles because of grid disturbances",
+
+	string(ConditionDiscovering):  "The Envoy is discovering the device",
+	string(ConditionFailure):      "The device failed",
+	string(ConditionFlashError):   "Updating the device's firmware failed",
+	string(ConditionNotMonitored): "The device is not monitored",
+	string(ConditionVerifying):    "The Envoy is verifying the device",
+
+	string(ConditionMeterCheck):        "The meter needs checking",
+	string(ConditionMeterPowerQuality): "The meter detected poor power quality",
+
+	// event log types
+	"grid instability":          "The grid voltage or frequency went outside the limits of the grid profile",
+	"grid gone":                 "The grid went down",
+	"ac voltage out of range":   "The grid voltage went outside the limits of the grid profile",
+	"ac frequency out of range": "The grid frequency went outside the limits of the grid profile",
+	"dc power too low":          "The DC input power was too low to produce, as at night",
+	"dc voltage too low":        "The DC input voltage was too low to produce",
+	"gfi tripped":               "The ground fault interrupter tripped",
+	"communication lost":        "The Envoy lost communication with the device",
+	"over temperature":          "The device was too hot and reduced its output",
+	"envoy boot":                "The Envoy restarted",
+	"firmware upgrade":          "The device's firmware was updated",
+}
+
+// Describe returns a description of a device condition flag or event type for humans, or "" if *code* is unknown
+func Describe(code string) string {
+	code = strings.TrimSpace(code)
+	if d, ok := descriptions[code]; ok {
+		return d
+	}
+	return descriptions[strings.ToLower(code)]
+}
+
+// Description describes the condition for humans, or returns "" if it is unknown
+func (c Condition) Description() string {
+	return Describe(string(c))
+}
+
+// Descriptions describes each condition, using the condition itself where it is unknown
+func (cs Conditions) Descriptions() []string {
+	d := make([]string, len(cs))
+	for i, c := range cs {
+		if d[i] = c.Description(); d[i] == "" {
+			d[i] = string(c)
+		}
+	}
+	return d
+}
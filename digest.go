@@ -0,0 +1,182 @@
+package envoy
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Legacy usernames accepted by firmware that predates JWT authentication
+const (
+	UserEnvoy     = "envoy"
+	UserInstaller = "installer"
+)
+
+// WithDigestAuth makes the Client authenticate with HTTP digest authentication instead of a JWT, as required by Envoys
+// running firmware older than 7.x. The username is usually UserEnvoy or UserInstaller.
+func WithDigestAuth(username, password string) Option {
+	return func(c *Client) {
+		c.digest = &digestTransport{username: username, password: password}
+	}
+}
+
+// digestTransport answers digest challenges on behalf of the requests passing through it. Once a challenge has been
+// seen, later requests are authenticated up front to save a round trip.
+type digestTransport struct {
+	username string
+	password string
+	next     http.RoundTripper
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        int
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	qop       string
+	algorithm string
+}
+
+func (t *digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if auth := t.authorization(req); auth != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := parseDigestChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge == nil {
+		return resp, nil
+	}
+	if req.Body != nil && req.GetBody == nil {
+		// the body has been consumed and cannot be replayed
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	t.mu.Lock()
+	t.challenge = challenge
+	t.nc = 0
+	t.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", t.authorization(retry))
+	return t.next.RoundTrip(retry)
+}
+
+// authorization computes the Authorization header for *req* from the last challenge, if any
+func (t *digestTransport) authorization(req *http.Request) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := t.challenge
+	if ch == nil {
+		return ""
+	}
+	t.nc++
+	nc := fmt.Sprintf("%08x", t.nc)
+	cnonce := newCnonce()
+	uri := req.URL.RequestURI()
+
+	ha1 := md5hex(t.username + ":" + ch.realm + ":" + t.password)
+	if strings.EqualFold(ch.algorithm, "MD5-sess") {
+		ha1 = md5hex(ha1 + ":" + ch.nonce + ":" + cnonce)
+	}
+	ha2 := md5hex(req.Method + ":" + uri)
+
+	var response string
+	if ch.qop != "" {
+		response = md5hex(strings.Join([]string{ha1, ch.nonce, nc, cnonce, ch.qop, ha2}, ":"))
+	} else {
+		response = md5hex(ha1 + ":" + ch.nonce + ":" + ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, t.username),
+		fmt.Sprintf(`realm="%s"`, ch.realm),
+		fmt.Sprintf(`nonce="%s"`, ch.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if ch.algorithm != "" {
+		fields = append(fields, "algorithm="+ch.algorithm)
+	}
+	if ch.opaque != "" {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, ch.opaque))
+	}
+	if ch.qop != "" {
+		fields = append(fields, "qop="+ch.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+// parseDigestChallenge parses a WWW-Authenticate header, returning nil if it is not a digest challenge
+func parseDigestChallenge(header string) *digestChallenge {
+	scheme, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return nil
+	}
+	ch := &digestChallenge{}
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if strings.HasPrefix(params, `"`) {
+			end := strings.Index(params[1:], `"`)
+			if end < 0 {
+				value, params = params[1:], ""
+			} else {
+				value, params = params[1:end+1], params[end+2:]
+			}
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "realm":
+			ch.realm = value
+		case "nonce":
+			ch.nonce = value
+		case "opaque":
+			ch.opaque = value
+		case "algorithm":
+			ch.algorithm = strings.TrimSpace(value)
+		case "qop":
+			// prefer auth when the server offers several protections
+			for _, q := range strings.Split(value, ",") {
+				if strings.TrimSpace(q) == "auth" {
+					ch.qop = "auth"
+				}
+			}
+		}
+	}
+	if ch.nonce == "" {
+		return nil
+	}
+	return ch
+}
+
+func md5hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCnonce() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Dry contact relay states
+const (
+	DryContactOpen   = "open"
+	DryContactClosed = "closed"
+)
+
+// DryContact is the state of a dry contact relay on the Enpower
+type DryContact struct {
+	// ID identifies the relay, e.g. NC1 or NO1
+	ID string `json:"id,omitempty"`
+	// Status is DryContactOpen or DryContactClosed
+	Status string `json:"status,omitempty"`
+}
+
+type dryContacts struct {
+	DryContacts []DryContact `json:"dry_contacts,omitempty"`
+}
+
+// DryContacts returns the state of each configured dry contact relay. Systems without an Enpower return a
+// *NotSupportedError for CapabilityEnpower.
+func (c *Client) DryContacts(ctx context.Context) ([]DryContact, error) {
+	var contacts dryContacts
+	err := c.getFeature(ctx, CapabilityEnpower, "/ivp/ensemble/dry_contacts", &contacts)
+	return contacts.DryContacts, err
+}
+
+// SetDryContact opens or closes the dry contact relay *id*, e.g. to shed a load while on battery. *status* is
+// DryContactOpen or DryContactClosed. The action is subject to the Client's ConfirmFunc.
+func (c *Client) SetDryContact(ctx context.Context, id, status string) error {
+	if status != DryContactOpen && status != DryContactClosed {
+		return fmt.Errorf("invalid dry contact status %q", status)
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("set dry contact %s %s", id, status)); err != nil {
+		return err
+	}
+	body := struct {
+		DryContacts DryContact `json:"dry_contacts"`
+	}{DryContact{ID: id, Status: status}}
+	return c.call(ctx, http.MethodPost, "/ivp/ensemble/dry_contacts", body, nil)
+}
+
+// DryContactSettings is the configuration of a dry contact relay from /ivp/ss/dry_contact_settings. The fields carry
+// no omitempty so that zero thresholds, priorities and cleared actions are written back.
+type DryContactSettings struct {
+	ID string `json:"id"`
+	// Type is NO (normally open) or NC (normally closed)
+	Type     string `json:"type"`
+	LoadName string `json:"load_name"`
+	// Mode is manual or soc
+	Mode string `json:"mode"`
+	// SOCLow and SOCHigh are the state of charge thresholds at which the relay is switched, in percent
+	SOCLow  int `json:"soc_low"`
+	SOCHigh int `json:"soc_high"`
+	// GridAction, MicroGridAction and GenAction are what happens to the load on grid, on battery and on generator:
+	// apply, shed or schedule
+	GridAction      string   `json:"grid_action"`
+	MicroGridAction string   `json:"micro_grid_action"`
+	GenAction       string   `json:"gen_action"`
+	Override        string   `json:"override"`
+	ManualOverride  string   `json:"manual_override"`
+	Priority        int      `json:"priority"`
+	PVSerialNB      []string `json:"pv_serial_nb"`
+	BlackSStart     string   `json:"black_s_start"`
+}
+
+type dryContactSettings struct {
+	DryContacts []DryContactSettings `json:"dry_contacts,omitempty"`
+}
+
+// DryContactSettings returns the configuration of each dry contact relay. Systems without an Enpower return a
+// *NotSupportedError for CapabilityEnpower.
+func (c *Client) DryContactSettings(ctx context.Context) ([]DryContactSettings, error) {
+	var settings dryContactSettings
+	err := c.getFeature(ctx, CapabilityEnpower, "/ivp/ss/dry_contact_settings", &settings)
+	return settings.DryContacts, err
+}
+
+// UpdateDryContactSettings replaces the configuration of the dry contact relay identified by settings.ID. The action
+// is subject to the Client's ConfirmFunc.
+func (c *Client) UpdateDryContactSettings(ctx context.Context, settings DryContactSettings) error {
+	if settings.ID == "" {
+		return fmt.Errorf("dry contact settings without id")
+	}
+	if settings.SOCLow < 0 || settings.SOCHigh > 100 || settings.SOCLow > settings.SOCHigh {
+		return fmt.Errorf("invalid dry contact thresholds %d-%d", settings.SOCLow, settings.SOCHigh)
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("update dry contact %s settings", settings.ID)); err != nil {
+		return err
+	}
+	if settings.PVSerialNB == nil {
+		settings.PVSerialNB = []string{}
+	}
+	body := struct {
+		DryContacts DryContactSettings `json:"dry_contacts"`
+	}{settings}
+	return c.call(ctx, http.MethodPost, "/ivp/ss/dry_contact_settings", body, nil)
+}
@@ -0,0 +1,36 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateDryContactSettingsWritesZeros(t *testing.T) {
+	f := newFakeEnvoy(t)
+	var body map[string]map[string]interface{}
+	f.handle("/ivp/ss/dry_contact_settings", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Errorf("decoding %s: %v", b, err)
+		}
+	})
+
+	// shedding the load as soon as the batteries run, whatever their charge
+	settings := DryContactSettings{ID: "NC1", Type: "NC", Mode: "soc", SOCLow: 0, SOCHigh: 0, GridAction: "apply",
+		MicroGridAction: "shed"}
+	if err := f.client().UpdateDryContactSettings(context.Background(), settings); err != nil {
+		t.Fatal(err)
+	}
+	sent := body["dry_contacts"]
+	for _, key := range []string{"soc_low", "soc_high", "priority", "gen_action", "override", "pv_serial_nb"} {
+		if _, ok := sent[key]; !ok {
+			t.Errorf("%s missing from %v", key, sent)
+		}
+	}
+	if serials, ok := sent["pv_serial_nb"].([]interface{}); !ok || len(serials) != 0 {
+		t.Errorf("sent pv_serial_nb %v, want []", sent["pv_serial_nb"])
+	}
+}
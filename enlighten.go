@@ -0,0 +1,82 @@
+package envoy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	enlightenLoginURL = "https://enlighten.enphaseenergy.com/login/login.json?"
+	entrezTokenURL    = "https://entrez.enphaseenergy.com/tokens"
+
+	enlightenClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// enlightenSession is the response of the Enlighten login endpoint
+type enlightenSession struct {
+	Message   string `json:"message,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// FetchToken logs into the Enlighten cloud with *email* and *password* and retrieves a local-access JWT for the Envoy
+// with serial number *serial*. Rejected credentials are reported as an *AuthError.
+func FetchToken(ctx context.Context, email, password, serial string) (string, error) {
+	form := url.Values{
+		"user[email]":    {email},
+		"user[password]": {password},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, enlightenLoginURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := enlightenClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return "", &AuthError{StatusCode: resp.StatusCode, Message: stripTags(string(body))}
+	}
+	var session enlightenSession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return "", err
+	}
+	if session.SessionID == "" {
+		return "", &AuthError{StatusCode: resp.StatusCode, Message: session.Message}
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"session_id": session.SessionID,
+		"serial_num": serial,
+		"username":   email,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, entrezTokenURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tokenResp, err := enlightenClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer tokenResp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(tokenResp.Body, maxErrorBody))
+	if err != nil {
+		return "", err
+	}
+	if tokenResp.StatusCode != http.StatusOK {
+		return "", &AuthError{StatusCode: tokenResp.StatusCode, Message: stripTags(string(body))}
+	}
+	return strings.TrimSpace(string(body)), nil
+}
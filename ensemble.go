@@ -0,0 +1,256 @@
+package envoy
+
+import "context"
+
+// Device types reported by /ivp/ensemble/inventory
+const (
+	EnsembleEncharge = "ENCHARGE"
+	EnsembleEnpower  = "ENPOWER"
+)
+
+// EnsembleDevice is an Encharge battery or Enpower system controller from /ivp/ensemble/inventory. Fields that only
+// apply to one kind of device are left empty for the other.
+type EnsembleDevice struct {
+	PartNum      string     `json:"part_num,omitempty"`
+	SerialNum    string     `json:"serial_num,omitempty"`
+	Installed    int64      `json:"installed,omitempty"`
+	DeviceStatus Conditions `json:"device_status,omitempty"`
+	// LastRptDate is when the device last reported
+	LastRptDate    Timestamp `json:"last_rpt_date,omitempty"`
+	AdminState     int       `json:"admin_state,omitempty"`
+	AdminStateStr  string    `json:"admin_state_str,omitempty"`
+	CreatedDate    Timestamp `json:"created_date,omitempty"`
+	ImgLoadDate    Timestamp `json:"img_load_date,omitempty"`
+	ImgPnumRunning string    `json:"img_pnum_running,omitempty"`
+	// ZigbeeDongleFwVersion and BMUFwVersion are firmware versions of the device's radio and battery management unit
+	ZigbeeDongleFwVersion string `json:"zigbee_dongle_fw_version,omitempty"`
+	BMUFwVersion          string `json:"bmu_fw_version,omitempty"`
+	Operating             bool   `json:"operating,omitempty"`
+	Communicating         bool   `json:"communicating,omitempty"`
+	SleepEnabled          bool   `json:"sleep_enabled,omitempty"`
+	// PercentFull is the Encharge state of charge
+	PercentFull int `json:"percentFull,omitempty"`
+	// Temperature and MaxCellTemp are in °C
+	Temperature     int  `json:"temperature,omitempty"`
+	MaxCellTemp     int  `json:"maxCellTemp,omitempty"`
+	CommLevelSubGHz int  `json:"comm_level_sub_ghz,omitempty"`
+	CommLevel24GHz  int  `json:"comm_level_2_4_ghz,omitempty"`
+	LEDStatus       int  `json:"led_status,omitempty"`
+	DCSwitchOff     bool `json:"dc_switch_off,omitempty"`
+	EnchargeRev     int  `json:"encharge_rev,omitempty"`
+	// EnchargeCapacity is the usable capacity, in Wh
+	EnchargeCapacity int    `json:"encharge_capacity,omitempty"`
+	Phase            string `json:"phase,omitempty"`
+	DERIndex         int    `json:"der_index,omitempty"`
+
+	// MainsAdminState and MainsOperState are the commanded and actual state of the Enpower grid relay, open or closed
+	MainsAdminState   string `json:"mains_admin_state,omitempty"`
+	MainsOperState    string `json:"mains_oper_state,omitempty"`
+	EnpwrGridMode     string `json:"Enpwr_grid_mode,omitempty"`
+	EnchgGridMode     string `json:"Enchg_grid_mode,omitempty"`
+	EnpwrRelayStateBm int    `json:"Enpwr_relay_state_bm,omitempty"`
+	EnpwrCurrStateID  int    `json:"Enpwr_curr_state_id,omitempty"`
+}
+
+// EnsembleGroup is a list of ensemble devices of one Type
+type EnsembleGroup struct {
+	Type    string           `json:"type,omitempty"`
+	Devices []EnsembleDevice `json:"devices,omitempty"`
+}
+
+// EnsembleInventory returns the Encharge batteries and Enpower system controllers managed by the Envoy. Systems
+// without them return a *NotSupportedError for CapabilityEncharge.
+func (c *Client) EnsembleInventory(ctx context.Context) ([]EnsembleGroup, error) {
+	var inventory []EnsembleGroup
+	err := c.getFeature(ctx, CapabilityEncharge, "/ivp/ensemble/inventory", &inventory)
+	return inventory, err
+}
+
+// Encharges returns just the Encharge batteries from EnsembleInventory.
+func (c *Client) Encharges(ctx context.Context) ([]EnsembleDevice, error) {
+	inventory, err := c.EnsembleInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var batteries []EnsembleDevice
+	for _, group := range inventory {
+		if group.Type == EnsembleEncharge {
+			batteries = append(batteries, group.Devices...)
+		}
+	}
+	if len(batteries) == 0 {
+		return nil, &NotSupportedError{Capability: CapabilityEncharge}
+	}
+	return batteries, nil
+}
+
+// EnchargePower is the real-time power of one Encharge from /ivp/ensemble/power. Positive power is discharging.
+type EnchargePower struct {
+	SerialNum string `json:"serial_num,omitempty"`
+	// RealPowerMw is in milliwatts and ApparentPowerMva in millivolt-amperes
+	RealPowerMw      int64 `json:"real_power_mw,omitempty"`
+	ApparentPowerMva int64 `json:"apparent_power_mva,omitempty"`
+	SOC              int   `json:"soc,omitempty"`
+}
+
+// Watts returns the real power in W
+func (p EnchargePower) Watts() Watts {
+	return Watts(p.RealPowerMw) / 1000
+}
+
+// ensemblePower is the response of /ivp/ensemble/power. Firmware spells the key "devices:", with the colon.
+type ensemblePower struct {
+	Devices      []EnchargePower `json:"devices,omitempty"`
+	DevicesColon []EnchargePower `json:"devices:,omitempty"`
+}
+
+// EnsemblePower returns the real-time charge or discharge power of each Encharge. Systems without batteries return a
+// *NotSupportedError for CapabilityEncharge.
+func (c *Client) EnsemblePower(ctx context.Context) ([]EnchargePower, error) {
+	var power ensemblePower
+	if err := c.getFeature(ctx, CapabilityEncharge, "/ivp/ensemble/power", &power); err != nil {
+		return nil, err
+	}
+	devices := append(power.Devices, power.DevicesColon...)
+	if len(devices) == 0 {
+		return nil, &NotSupportedError{Capability: CapabilityEncharge}
+	}
+	return devices, nil
+}
+
+// SecCtrl is the aggregate battery state from /ivp/ensemble/secctrl
+type SecCtrl struct {
+	// Shutdown is set once the batteries have discharged to their shutdown level
+	Shutdown       bool    `json:"shutdown,omitempty"`
+	FreqBiasHz     float64 `json:"freq_bias_hz,omitempty"`
+	VoltageBiasV   float64 `json:"voltage_bias_v,omitempty"`
+	FreqBiasHzQ8   int     `json:"freq_bias_hz_q8,omitempty"`
+	VoltageBiasVQ5 int     `json:"voltage_bias_v_q5,omitempty"`
+	// ConfiguredBackupSOC is the backup reserve percentage, and AdjustedBackupSOC the reserve currently applied
+	ConfiguredBackupSOC int `json:"configured_backup_soc,omitempty"`
+	AdjustedBackupSOC   int `json:"adjusted_backup_soc,omitempty"`
+	// AggSOC is the combined state of charge of all batteries
+	AggSOC int `json:"agg_soc,omitempty"`
+	// MaxEnergy and the capacity and energy fields are in Wh
+	MaxEnergy               int `json:"Max_energy,omitempty"`
+	ENCCommissionedCapacity int `json:"ENC_commissioned_capacity,omitempty"`
+	ENCMaxAvailableCapacity int `json:"ENC_max_available_capacity,omitempty"`
+	ACBCommissionedCapacity int `json:"ACB_commissioned_capacity,omitempty"`
+	ACBMaxAvailableCapacity int `json:"ACB_max_available_capacity,omitempty"`
+	ACBAggSOC               int `json:"ACB_agg_soc,omitempty"`
+	ACBAggEnergy            int `json:"ACB_agg_energy,omitempty"`
+	EncAggAvailEnergy       int `json:"Enc_agg_avail_energy,omitempty"`
+	EncAggBackupEnergy      int `json:"Enc_agg_backup_energy,omitempty"`
+	EncAggSOC               int `json:"Enc_agg_soc,omitempty"`
+	// VeryLowSOC is the shutdown level, below which the batteries stop discharging
+	VeryLowSOC int `json:"very_low_soc,omitempty"`
+}
+
+// EnsembleRelay is the state of the grid relay reported along with the battery state
+type EnsembleRelay struct {
+	// MainsAdminState and MainsOperState are the commanded and actual state of the grid relay, open or closed
+	MainsAdminState string `json:"mains_admin_state,omitempty"`
+	MainsOperState  string `json:"mains_oper_state,omitempty"`
+	DER1State       int    `json:"der1_state,omitempty"`
+	DER2State       int    `json:"der2_state,omitempty"`
+	DER3State       int    `json:"der3_state,omitempty"`
+	EnchgGridMode   string `json:"Enchg_grid_mode,omitempty"`
+	SolarGridMode   string `json:"Solar_grid_mode,omitempty"`
+}
+
+// GridTied reports whether the grid relay is closed, i.e. the system is not running on backup
+func (r EnsembleRelay) GridTied() bool {
+	return r.MainsOperState == "closed"
+}
+
+// EnsembleSecCtrlStatus is the response of /ivp/ensemble/secctrl
+type EnsembleSecCtrlStatus struct {
+	SecCtrl SecCtrl        `json:"secctrl,omitempty"`
+	Relay   *EnsembleRelay `json:"relay,omitempty"`
+}
+
+// EnsembleSecCtrl returns the combined battery state of charge, backup reserve and shutdown level, and the state of
+// the grid relay where the firmware reports it. Systems without batteries return a *NotSupportedError for
+// CapabilityEncharge.
+func (c *Client) EnsembleSecCtrl(ctx context.Context) (EnsembleSecCtrlStatus, error) {
+	var status EnsembleSecCtrlStatus
+	err := c.getFeature(ctx, CapabilityEncharge, "/ivp/ensemble/secctrl", &status)
+	return status, err
+}
+
+// EnsembleStatusDevice is the status of one ensemble device as reported by /ivp/ensemble/status
+type EnsembleStatusDevice struct {
+	DeviceType      string `json:"device_type,omitempty"`
+	CommLevelSubGHz int    `json:"comm_level_sub_ghz,omitempty"`
+	CommLevel24GHz  int    `json:"comm_level_2_4_ghz,omitempty"`
+	Running         bool   `json:"running,omitempty"`
+	Phase           string `json:"phase,omitempty"`
+	EnvoyPhase      string `json:"envoy_phase,omitempty"`
+	DmirVersion     int    `json:"dmir_version,omitempty"`
+	AdminState      int    `json:"admin_state,omitempty"`
+	MsgRetryCount   int    `json:"msg_retry_count,omitempty"`
+	PartNumber      string `json:"part_number,omitempty"`
+	// ReportedGridMode is e.g. multimode-ongrid or multimode-offgrid
+	ReportedGridMode  string `json:"reported_grid_mode,omitempty"`
+	ReportedGridState string `json:"reported_grid_state,omitempty"`
+	// Frequency in Hz and Voltage in V are measured at the device
+	Frequency float64 `json:"frequency,omitempty"`
+	Voltage   float64 `json:"voltage,omitempty"`
+}
+
+// EnsembleStatus is the response of /ivp/ensemble/status
+type EnsembleStatus struct {
+	Inventory struct {
+		SerialNums map[string]EnsembleStatusDevice `json:"serial_nums,omitempty"`
+	} `json:"inventory,omitempty"`
+	Counters map[string]float64 `json:"counters,omitempty"`
+	SecCtrl  SecCtrl            `json:"secctrl,omitempty"`
+	Relay    EnsembleRelay      `json:"relay,omitempty"`
+}
+
+// EnsembleStatus returns the status of every ensemble device along with the grid relay. Systems without ensemble
+// devices return a *NotSupportedError for CapabilityEnpower.
+func (c *Client) EnsembleStatus(ctx context.Context) (EnsembleStatus, error) {
+	var status EnsembleStatus
+	err := c.getFeature(ctx, CapabilityEnpower, "/ivp/ensemble/status", &status)
+	return status, err
+}
+
+// EnpowerStatus is the state of an Enpower (IQ System Controller) and its grid relay
+type EnpowerStatus struct {
+	SerialNum string
+	// GridConnected reports whether the grid relay is closed; false means the home is islanded
+	GridConnected bool
+	// MainsAdminState and MainsOperState are the commanded and actual state of the grid relay, open or closed
+	MainsAdminState string
+	MainsOperState  string
+	// GridMode is e.g. multimode-ongrid or multimode-offgrid
+	GridMode string
+	// Frequency in Hz and Voltage in V are measured at the relay
+	Frequency float64
+	Voltage   float64
+}
+
+// EnpowerStatus returns the relay state and measurements of the Enpower, for monitoring islanding events. Systems
+// without an Enpower return a *NotSupportedError for CapabilityEnpower.
+func (c *Client) EnpowerStatus(ctx context.Context) (EnpowerStatus, error) {
+	status, err := c.EnsembleStatus(ctx)
+	if err != nil {
+		return EnpowerStatus{}, err
+	}
+	for serial, device := range status.Inventory.SerialNums {
+		if device.DeviceType != EnsembleEnpower {
+			continue
+		}
+		return EnpowerStatus{
+			SerialNum:       serial,
+			GridConnected:   status.Relay.GridTied(),
+			MainsAdminState: status.Relay.MainsAdminState,
+			MainsOperState:  status.Relay.MainsOperState,
+			GridMode:        device.ReportedGridMode,
+			Frequency:       device.Frequency,
+			Voltage:         device.Voltage,
+		}, nil
+	}
+	return EnpowerStatus{}, &NotSupportedError{Capability: CapabilityEnpower}
+}
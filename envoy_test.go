@@ -0,0 +1,122 @@
+package envoy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testToken = "test-token"
+
+// fakeEnvoy is a firmware 7.x Envoy that hands out a session cookie for a valid token and serves the handlers
+// registered on it to requests carrying a live session
+type fakeEnvoy struct {
+	*httptest.Server
+	t *testing.T
+
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	sessions map[string]bool
+	logins   int
+	requests map[string]int
+	// rejectAll answers every data request with 401, even with a live session
+	rejectAll bool
+	// firmware is the reported firmware version. Before 7.x data is served without a session.
+	firmware string
+}
+
+func newFakeEnvoy(t *testing.T) *fakeEnvoy {
+	f := &fakeEnvoy{
+		t:        t,
+		handlers: make(map[string]http.HandlerFunc),
+		sessions: make(map[string]bool),
+		requests: make(map[string]int),
+		firmware: "D7.0.88",
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// client returns a Client for the fake Envoy configured with *opts* after the defaults
+func (f *fakeEnvoy) client(opts ...Option) *Client {
+	opts = append([]Option{WithProto("http"), WithToken(testToken), WithRetryPolicy(NoRetry)}, opts...)
+	return NewClient(strings.TrimPrefix(f.URL, "http://"), opts...)
+}
+
+// handle serves *path* with *h*
+func (f *fakeEnvoy) handle(path string, h http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[path] = h
+}
+
+// handleJSON serves *body* as JSON at *path*
+func (f *fakeEnvoy) handleJSON(path, body string) {
+	f.handle(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+}
+
+// expire ends every session, as an Envoy does when it reboots
+func (f *fakeEnvoy) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = make(map[string]bool)
+}
+
+func (f *fakeEnvoy) loginCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins
+}
+
+func (f *fakeEnvoy) requestCount(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+func (f *fakeEnvoy) serve(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/info":
+		fmt.Fprintf(w, `<envoy_info><device><sn>122012345678</sn><software>%s</software></device></envoy_info>`, f.firmware)
+		return
+	case "/auth/check_jwt":
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		f.logins++
+		session := strconv.Itoa(f.logins)
+		f.sessions[session] = true
+		f.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "sessionId", Value: session, Path: "/"})
+		w.Write([]byte("Valid token."))
+		return
+	}
+
+	f.mu.Lock()
+	f.requests[r.URL.Path]++
+	h := f.handlers[r.URL.Path]
+	authorized := !f.rejectAll
+	if cookie, err := r.Cookie("sessionId"); strings.HasPrefix(f.firmware, "D7") &&
+		(err != nil || !f.sessions[cookie.Value]) {
+		authorized = false
+	}
+	f.mu.Unlock()
+	switch {
+	case !authorized:
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	case h == nil:
+		http.NotFound(w, r)
+	default:
+		h(w, r)
+	}
+}
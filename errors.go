@@ -0,0 +1,117 @@
+package envoy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized is matched by errors for requests the Envoy answered with 401
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is matched by errors for requests the Envoy answered with 403, e.g. an owner token on an installer
+	// endpoint
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound is matched by errors for requests the Envoy answered with 404
+	ErrNotFound = errors.New("not found")
+	// ErrFirmwareUnsupported is matched by errors for features the Envoy's firmware does not provide
+	ErrFirmwareUnsupported = errors.New("not supported by firmware")
+)
+
+// maxAPIErrorBody caps how much of a response body an APIError keeps
+const maxAPIErrorBody = 512
+
+// APIError is returned when an Envoy API responds with a status other than 200. It matches ErrNotOK with errors.Is,
+// as well as the sentinel for its class of failure given in Err.
+type APIError struct {
+	// StatusCode is the HTTP status returned by the Envoy
+	StatusCode int
+	// Method and Path identify the request that failed
+	Method string
+	Path   string
+	// Body is the beginning of the response body, truncated to a few hundred bytes
+	Body string
+	// Err is the sentinel classifying the failure, such as ErrUnauthorized or ErrNotFound, or nil
+	Err error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s (status %d)", e.Method, e.Path, ErrNotOK, e.StatusCode)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is reports whether target is ErrNotOK
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotOK
+}
+
+// Unwrap returns the sentinel classifying the failure
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// statusError returns the sentinel for a response status, or nil if there is none
+func statusError(status int) error {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusNotImplemented:
+		return ErrFirmwareUnsupported
+	}
+	return nil
+}
+
+// newAPIError builds an APIError from *resp*, consuming part of its body
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxAPIErrorBody))
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		// truncation may have cut a multi-byte character in half
+		Body: stripTags(strings.ToValidUTF8(string(body), "")),
+		Err:  statusError(resp.StatusCode),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.Path = resp.Request.URL.RequestURI()
+	}
+	return e
+}
+
+// ErrNotSupported is matched by errors for features the Envoy's hardware or firmware does not provide, such as
+// meter readings on a system without CTs
+var ErrNotSupported = errors.New("not supported")
+
+// NotSupportedError is returned when an endpoint is missing or empty because the Envoy lacks a capability. It matches
+// ErrNotSupported with errors.Is, as well as the underlying error in Err.
+type NotSupportedError struct {
+	// Capability is the feature that is missing
+	Capability Capability
+	// Err is the error that revealed the missing feature, if any
+	Err error
+}
+
+func (e *NotSupportedError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.Capability, ErrNotSupported)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Capability, ErrNotSupported, e.Err)
+}
+
+// Is reports whether target is ErrNotSupported
+func (e *NotSupportedError) Is(target error) bool {
+	return target == ErrNotSupported
+}
+
+// Unwrap returns the error that revealed the missing feature
+func (e *NotSupportedError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,195 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity classifies events and device conditions
+type Severity int
+
+// Severities, in increasing order
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// eventTimeLayout is how the event log formats timestamps
+const eventTimeLayout = "Mon Jan 02, 2006 03:04 PM MST"
+
+// DefaultEventPageSize is the number of events fetched per request unless EventOptions says otherwise
+const DefaultEventPageSize = 100
+
+// Event is an entry of the Envoy event log
+type Event struct {
+	ID int64
+	// Timestamp is when the event occurred, or the zero time, logged as a warning, if the Envoy's date could not be
+	// parsed
+	Timestamp time.Time
+	// Device describes the device the event concerns, and SerialNumber is its serial number, if any
+	Device       string
+	SerialNumber string
+	// Type is the event description, e.g. "Grid Instability"
+	Type     string
+	Severity Severity
+	// Description explains Type for humans, if it is a known event type
+	Description string
+}
+
+// eventPage is a page of the event log in the DataTables format the Envoy serves it in
+type eventPage struct {
+	TotalRecords int        `json:"iTotalRecords,omitempty"`
+	Data         [][]string `json:"aaData,omitempty"`
+}
+
+// EventOptions controls which events Events returns
+type EventOptions struct {
+	// Start is the offset of the first event, with 0 the most recent
+	Start int
+	// PageSize is the number of events fetched per request, DefaultEventPageSize if zero
+	PageSize int
+}
+
+// EventIterator walks the event log a page at a time
+//
+//	it := client.Events(ctx, envoy.EventOptions{})
+//	for it.Next() {
+//		event := it.Event()
+//	}
+//	if err := it.Err(); err != nil {
+type EventIterator struct {
+	c     *Client
+	ctx   context.Context
+	next  int
+	size  int
+	total int
+	page  []Event
+	cur   Event
+	err   error
+	done  bool
+}
+
+// Events returns an iterator over the event log, most recent first.
+func (c *Client) Events(ctx context.Context, opts EventOptions) *EventIterator {
+	size := opts.PageSize
+	if size <= 0 {
+		size = DefaultEventPageSize
+	}
+	return &EventIterator{c: c, ctx: ctx, next: opts.Start, size: size, total: -1}
+}
+
+// Next advances to the next event, fetching another page when needed. It returns false once the log is exhausted or
+// an error occurs.
+func (it *EventIterator) Next() bool {
+	if len(it.page) == 0 && !it.done {
+		it.fetch()
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Event returns the current event
+func (it *EventIterator) Event() Event {
+	return it.cur
+}
+
+// Total returns the number of events in the log, known once the first page has been fetched, or -1
+func (it *EventIterator) Total() int {
+	return it.total
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *EventIterator) Err() error {
+	return it.err
+}
+
+func (it *EventIterator) fetch() {
+	var page eventPage
+	path := fmt.Sprintf("/datatab/event_dt.rb?start=%d&length=%d", it.next, it.size)
+	if err := it.c.get(it.ctx, path, &page); err != nil {
+		it.err, it.done = err, true
+		return
+	}
+	// the dates carry only a zone abbreviation such as PST, which means something only in the Envoy's own zone
+	envoyLoc, err := it.c.Location(it.ctx)
+	if err != nil {
+		it.err, it.done = fmt.Errorf("reading the envoy's timezone for event times: %w", err), true
+		return
+	}
+	it.total = page.TotalRecords
+	loc := it.c.timeLocation(it.ctx)
+	for _, row := range page.Data {
+		e, err := parseEventRow(row, envoyLoc)
+		if err != nil {
+			it.c.logger.WarnContext(it.ctx, "leaving an unparseable event time zero", "error", err)
+		} else {
+			e.Timestamp = e.Timestamp.In(loc)
+		}
+		it.page = append(it.page, e)
+	}
+	it.next += len(page.Data)
+	if len(page.Data) < it.size || it.next >= it.total {
+		it.done = true
+	}
+}
+
+// parseEventRow decodes a row of id, description, device and date, reading the date's zone abbreviation in *loc*,
+// which must be the Envoy's own zone. The event is returned even if its date cannot be parsed.
+func parseEventRow(row []string, loc *time.Location) (Event, error) {
+	field := func(i int) string {
+		if i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	e := Event{Type: field(1), Device: field(2)}
+	e.ID, _ = strconv.ParseInt(field(0), 10, 64)
+	if i := strings.LastIndexByte(e.Device, ' '); i >= 0 {
+		serial := e.Device[i+1:]
+		if _, err := strconv.ParseUint(serial, 10, 64); err == nil {
+			e.SerialNumber = serial
+		}
+	}
+	e.Severity = eventSeverity(e.Type)
+	e.Description = Describe(e.Type)
+	t, err := time.ParseInLocation(eventTimeLayout, field(3), loc)
+	if err != nil {
+		return e, fmt.Errorf("event %d: %w", e.ID, err)
+	}
+	e.Timestamp = t
+	return e, nil
+}
+
+// eventSeverity guesses the severity of an event from its description. A fault that cleared is informational, but
+// one merely "detected" is still a fault.
+func eventSeverity(description string) Severity {
+	d := strings.ToLower(description)
+	switch {
+	case strings.Contains(d, "cleared"), strings.Contains(d, "restored"):
+		return SeverityInfo
+	case strings.Contains(d, "fail"), strings.Contains(d, "fault"), strings.Contains(d, "error"):
+		return SeverityError
+	case strings.Contains(d, "instability"), strings.Contains(d, "low"), strings.Contains(d, "high"), strings.Contains(d, "lost"):
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
@@ -0,0 +1,54 @@
+package envoy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventSeverity(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		want        Severity
+	}{
+		{"GFI fault detected", SeverityError},
+		{"Grid failure detected", SeverityError},
+		{"GFI fault cleared", SeverityInfo},
+		{"Grid power restored", SeverityInfo},
+		{"Grid instability detected", SeverityWarning},
+		{"New device detected", SeverityInfo},
+	} {
+		if got := eventSeverity(tc.description); got != tc.want {
+			t.Errorf("eventSeverity(%q) = %v, want %v", tc.description, got, tc.want)
+		}
+	}
+}
+
+func TestEventTimesInEnvoyZone(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON(dateTimePath, `{"tz":"US/Pacific"}`)
+	f.handleJSON("/datatab/event_dt.rb", `{"iTotalRecords":2,"aaData":[
+		["2","Grid Instability","Envoy 122012345678","Mon Jan 15, 2024 03:04 PM PST"],
+		["1","Power On","Envoy 122012345678","sometime yesterday"]
+	]}`)
+	c := f.client()
+
+	it := c.Events(context.Background(), EventOptions{})
+	var events []Event
+	for it.Next() {
+		events = append(events, it.Event())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	want := time.Date(2024, 1, 15, 23, 4, 0, 0, time.UTC)
+	if got := events[0].Timestamp; !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if !events[1].Timestamp.IsZero() {
+		t.Errorf("unparseable date gave %v, want the zero time", events[1].Timestamp)
+	}
+}
@@ -0,0 +1,415 @@
+// Package influx writes polled samples to InfluxDB 1.x or 2.x in line protocol, batching the points and retrying
+// transient failures:
+//
+//	w := influx.NewWriter("http://localhost:8086", influx.WithV2("home", "solar", token),
+//		influx.WithTags(map[string]string{"site": "home"}))
+//	defer w.Flush(ctx)
+//	go envoy.Drain(ctx, poller.Subscribe(16), w)
+package influx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gcochard/go-envoy"
+)
+
+// Defaults of a Writer
+const (
+	DefaultMeasurement   = "envoy"
+	DefaultDatabase      = "envoy"
+	DefaultBatchSize     = 500
+	DefaultFlushInterval = 10 * time.Second
+	// DefaultMaxBuffered is how many points are kept while InfluxDB is unreachable before the oldest are dropped
+	DefaultMaxBuffered = 10000
+)
+
+// ErrInvalidPoint is returned for a Sample that cannot be written in line protocol, e.g. one with a NaN reading
+var ErrInvalidPoint = errors.New("influx: invalid point")
+
+// Times line protocol can represent, as nanoseconds since the epoch in an int64
+var (
+	minTime = time.Unix(0, math.MinInt64)
+	maxTime = time.Unix(0, math.MaxInt64)
+)
+
+// WriteError is returned when InfluxDB rejects a write
+type WriteError struct {
+	StatusCode int
+	// Message is the beginning of the response body
+	Message string
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("influx: write failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Writer is an envoy.Sink writing Samples to InfluxDB. Points are sent once a batch is full or the oldest buffered
+// point is older than the flush interval; call Flush to send the rest.
+type Writer struct {
+	url           string
+	client        *http.Client
+	database      string
+	retention     string
+	username      string
+	password      string
+	org           string
+	bucket        string
+	token         string
+	v2            bool
+	measurement   string
+	tags          map[string]string
+	batchSize     int
+	flushInterval time.Duration
+	maxBuffered   int
+	retry         envoy.RetryPolicy
+
+	mu     sync.Mutex
+	lines  []string
+	oldest time.Time
+}
+
+// Option configures a Writer
+type Option func(*Writer)
+
+// WithV1 writes to *database* of InfluxDB 1.x with its default retention policy, authenticating with *username*
+// and *password* unless they are empty. Without WithV1 or WithV2, a Writer writes to DefaultDatabase on 1.x.
+func WithV1(database, username, password string) Option {
+	return func(w *Writer) {
+		w.database, w.username, w.password, w.v2 = database, username, password, false
+	}
+}
+
+// WithRetentionPolicy writes to the retention policy *rp* of InfluxDB 1.x
+func WithRetentionPolicy(rp string) Option {
+	return func(w *Writer) {
+		w.retention = rp
+	}
+}
+
+// WithV2 writes to *bucket* of *org* on InfluxDB 2.x, authenticating with the API *token*
+func WithV2(org, bucket, token string) Option {
+	return func(w *Writer) {
+		w.org, w.bucket, w.token, w.v2 = org, bucket, token, true
+	}
+}
+
+// WithHTTPClient sends the writes with *client* instead of http.DefaultClient
+func WithHTTPClient(client *http.Client) Option {
+	return func(w *Writer) {
+		w.client = client
+	}
+}
+
+// WithMeasurement names the measurement of the points, DefaultMeasurement by default
+func WithMeasurement(name string) Option {
+	return func(w *Writer) {
+		w.measurement = name
+	}
+}
+
+// WithTags adds *tags* to every point, e.g. the site name. Points are also tagged with their source and, for
+// inverters, serial.
+func WithTags(tags map[string]string) Option {
+	return func(w *Writer) {
+		for k, v := range tags {
+			w.tags[k] = v
+		}
+	}
+}
+
+// WithBatchSize sends the points *n* at a time
+func WithBatchSize(n int) Option {
+	return func(w *Writer) {
+		w.batchSize = n
+	}
+}
+
+// WithFlushInterval sends buffered points once the oldest has waited *d*
+func WithFlushInterval(d time.Duration) Option {
+	return func(w *Writer) {
+		w.flushInterval = d
+	}
+}
+
+// WithMaxBuffered keeps at most *n* points while InfluxDB is unreachable
+func WithMaxBuffered(n int) Option {
+	return func(w *Writer) {
+		w.maxBuffered = n
+	}
+}
+
+// WithRetryPolicy sets how failed writes are retried, envoy.DefaultRetryPolicy by default. POSTs of line protocol
+// are idempotent, so RetryNonIdempotent is not needed.
+func WithRetryPolicy(policy envoy.RetryPolicy) Option {
+	return func(w *Writer) {
+		w.retry = policy
+	}
+}
+
+// NewWriter creates a Writer for the InfluxDB server at *serverURL*, e.g. http://localhost:8086
+func NewWriter(serverURL string, opts ...Option) *Writer {
+	w := &Writer{
+		url:           strings.TrimSuffix(serverURL, "/"),
+		client:        http.DefaultClient,
+		database:      DefaultDatabase,
+		measurement:   DefaultMeasurement,
+		tags:          make(map[string]string),
+		batchSize:     DefaultBatchSize,
+		flushInterval: DefaultFlushInterval,
+		maxBuffered:   DefaultMaxBuffered,
+		retry:         envoy.DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Write buffers the Samples of *u* and sends them if a batch is due. Samples that cannot be encoded are skipped and
+// reported with ErrInvalidPoint, without holding up the rest.
+func (w *Writer) Write(ctx context.Context, u envoy.Update) error {
+	samples := envoy.Samples(u)
+	if len(samples) == 0 {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var invalid error
+	for _, s := range samples {
+		line, err := Line(w.measurement, w.tags, s)
+		if err != nil {
+			invalid = errors.Join(invalid, err)
+			continue
+		}
+		if len(w.lines) == 0 {
+			w.oldest = time.Now()
+		}
+		w.lines = append(w.lines, line)
+	}
+	if len(w.lines) == 0 || len(w.lines) < w.batchSize && time.Since(w.oldest) < w.flushInterval {
+		return invalid
+	}
+	return errors.Join(invalid, w.flush(ctx))
+}
+
+// Flush sends all buffered points
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush(ctx)
+}
+
+// flush sends the buffered points a batch at a time; the caller holds mu. Points that could not be sent stay
+// buffered for the next flush, unless InfluxDB rejected them as malformed or too large, in which case the first
+// rejection is returned once the rest have been sent.
+func (w *Writer) flush(ctx context.Context) error {
+	var rejection error
+	for len(w.lines) > 0 {
+		n := len(w.lines)
+		if w.batchSize > 0 && n > w.batchSize {
+			n = w.batchSize
+		}
+		done, rejected, err := w.deliver(ctx, w.lines[:n])
+		w.lines = w.lines[done:]
+		if rejection == nil {
+			rejection = rejected
+		}
+		if err != nil {
+			if over := len(w.lines) - w.maxBuffered; w.maxBuffered > 0 && over > 0 {
+				w.lines = w.lines[over:]
+			}
+			return errors.Join(rejection, err)
+		}
+	}
+	w.lines = nil
+	return rejection
+}
+
+// deliver sends *lines*. If InfluxDB rejects them, it sends each half on its own, so a bad point or an oversized
+// batch only costs the points InfluxDB refuses one at a time; points already accepted are overwritten with the same
+// values. It returns how many of *lines* were sent or dropped, the first rejection of a dropped point, and the
+// failure that stopped it, if any.
+func (w *Writer) deliver(ctx context.Context, lines []string) (done int, rejected, err error) {
+	err = w.send(ctx, lines)
+	switch {
+	case err == nil:
+		return len(lines), nil, nil
+	case !refused(err):
+		return 0, nil, err
+	case len(lines) == 1:
+		return 1, err, nil
+	}
+	half := len(lines) / 2
+	done, rejected, err = w.deliver(ctx, lines[:half])
+	if err != nil {
+		return done, rejected, err
+	}
+	rest, restRejected, err := w.deliver(ctx, lines[half:])
+	if rejected == nil {
+		rejected = restRejected
+	}
+	return half + rest, rejected, err
+}
+
+// send writes *lines*, retrying transient failures
+func (w *Writer) send(ctx context.Context, lines []string) error {
+	body := []byte(strings.Join(lines, "\n"))
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil || ctx.Err() != nil || w.permanent(err) || attempt >= w.retry.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.retry.Backoff(attempt)):
+		}
+	}
+}
+
+// refused reports whether *err* is InfluxDB refusing the points themselves, as malformed or too large, so sending
+// them again is pointless. Other refusals, e.g. a bad token or a missing bucket, say nothing about the points.
+func refused(err error) bool {
+	var werr *WriteError
+	return errors.As(err, &werr) && (werr.StatusCode == http.StatusBadRequest ||
+		werr.StatusCode == http.StatusRequestEntityTooLarge)
+}
+
+// permanent reports whether *err* is a status InfluxDB answers again however often the write is retried
+func (w *Writer) permanent(err error) bool {
+	var werr *WriteError
+	return errors.As(err, &werr) && !w.retryable(werr.StatusCode)
+}
+
+func (w *Writer) retryable(status int) bool {
+	for _, s := range w.retry.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+	return status >= http.StatusInternalServerError
+}
+
+func (w *Writer) post(ctx context.Context, body []byte) error {
+	q := url.Values{}
+	endpoint := w.url + "/write"
+	if w.v2 {
+		endpoint = w.url + "/api/v2/write"
+		q.Set("org", w.org)
+		q.Set("bucket", w.bucket)
+	} else {
+		q.Set("db", w.database)
+		if w.retention != "" {
+			q.Set("rp", w.retention)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"?"+q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case w.v2:
+		req.Header.Set("Authorization", "Token "+w.token)
+	case w.username != "":
+		req.SetBasicAuth(w.username, w.password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return &WriteError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+// Line encodes *s* as a point of *measurement* in line protocol, tagged with *tags*, its source and its serial
+// number. Readings the sample does not have are left out of its fields. It returns ErrInvalidPoint for readings that
+// are not finite, times line protocol cannot represent, and names containing line breaks.
+func Line(measurement string, tags map[string]string, s envoy.Sample) (string, error) {
+	if s.Time.IsZero() || s.Time.Before(minTime) || s.Time.After(maxTime) {
+		return "", fmt.Errorf("%w: time %v", ErrInvalidPoint, s.Time)
+	}
+	var b strings.Builder
+	b.WriteString(escape(measurement, ", "))
+	all := map[string]string{"source": s.Source}
+	if s.Serial != "" {
+		all["serial"] = s.Serial
+	}
+	for k, v := range tags {
+		all[k] = v
+	}
+	keys := make([]string, 0, len(all))
+	for k, v := range all {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	// InfluxDB prefers tags sorted by key
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString("," + escape(k, ",= ") + "=" + escape(all[k], ",= "))
+	}
+	if strings.ContainsAny(b.String(), "\r\n") {
+		return "", fmt.Errorf("%w: line break in measurement or tags %q", ErrInvalidPoint, b.String())
+	}
+	fields := []struct {
+		key   string
+		value float64
+		ok    bool
+	}{
+		{"production_w", float64(s.Production), true},
+		{"consumption_w", float64(s.Consumption), s.HasConsumption},
+		{"net_w", float64(s.Net), s.HasConsumption},
+		{"battery_w", float64(s.Battery), s.HasBattery},
+		{"soc", s.SOC, s.HasBattery},
+	}
+	sep := " "
+	for _, f := range fields {
+		if !f.ok {
+			continue
+		}
+		if math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			return "", fmt.Errorf("%w: %s is %v", ErrInvalidPoint, f.key, f.value)
+		}
+		b.WriteString(sep + f.key + "=" + number(f.value))
+		sep = ","
+	}
+	b.WriteString(" " + strconv.FormatInt(s.Time.UnixNano(), 10))
+	return b.String(), nil
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// escape backslash-escapes the characters of *special* in *s*
+func escape(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcochard/go-envoy"
+)
+
+var testTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeInflux records the lines of each write. It answers the first *unavailable* writes with 503, every write with
+// 401 while *unauthorized* is set, and rejects any write containing a point from the source "bad" with 400.
+type fakeInflux struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	unavailable  int
+	unauthorized bool
+	posts        int
+	lines        []string
+}
+
+func newFakeInflux(t *testing.T) *fakeInflux {
+	f := &fakeInflux{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.posts++
+		switch {
+		case f.unauthorized:
+			http.Error(w, "unauthorized access", http.StatusUnauthorized)
+		case f.unavailable > 0:
+			f.unavailable--
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case strings.Contains(string(body), "source=bad"):
+			http.Error(w, "unable to parse", http.StatusBadRequest)
+		default:
+			f.lines = append(f.lines, strings.Split(string(body), "\n")...)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeInflux) written() (posts int, lines []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.posts, append([]string(nil), f.lines...)
+}
+
+func update(source string, watts envoy.Watts) envoy.Update {
+	return envoy.Update{Source: source, Time: testTime, Value: envoy.Readings{Time: testTime, Production: watts}}
+}
+
+func TestLineEscaping(t *testing.T) {
+	line, err := Line("solar power", map[string]string{"site name": "home, east=1"}, envoy.Sample{
+		Time:           testTime,
+		Source:         "production",
+		Production:     250.5,
+		Consumption:    400,
+		Net:            149.5,
+		HasConsumption: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `solar\ power,site\ name=home\,\ east\=1,source=production production_w=250.5,consumption_w=400,net_w=149.5 ` +
+		"1717243200000000000"
+	if line != want {
+		t.Errorf("got  %s\nwant %s", line, want)
+	}
+}
+
+func TestLineInvalid(t *testing.T) {
+	for name, s := range map[string]envoy.Sample{
+		"NaN":        {Time: testTime, Production: envoy.Watts(math.NaN())},
+		"infinite":   {Time: testTime, SOC: math.Inf(1), HasBattery: true},
+		"zero time":  {},
+		"far future": {Time: time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"line break": {Time: testTime, Source: "a\nb"},
+	} {
+		if _, err := Line(DefaultMeasurement, nil, s); !errors.Is(err, ErrInvalidPoint) {
+			t.Errorf("%s: got %v, want ErrInvalidPoint", name, err)
+		}
+	}
+}
+
+func TestWriteSkipsInvalid(t *testing.T) {
+	f := newFakeInflux(t)
+	w := NewWriter(f.URL, WithBatchSize(1))
+	ctx := context.Background()
+	invalid := envoy.Update{Source: "production", Time: testTime, Value: []envoy.Inverter{
+		{SerialNumber: "1", LastReportDate: envoy.Timestamp{Time: testTime}, LastReportWatts: 250},
+		{SerialNumber: "2", LastReportDate: envoy.Timestamp{Time: time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}}
+	if err := w.Write(ctx, invalid); !errors.Is(err, ErrInvalidPoint) {
+		t.Errorf("got %v, want ErrInvalidPoint", err)
+	}
+	if _, lines := f.written(); len(lines) != 1 || !strings.Contains(lines[0], "serial=1") {
+		t.Errorf("wrote %q, want only inverter 1", lines)
+	}
+}
+
+func TestBatching(t *testing.T) {
+	f := newFakeInflux(t)
+	w := NewWriter(f.URL, WithBatchSize(2), WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := w.Write(ctx, update("production", envoy.Watts(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if posts, lines := f.written(); posts != 1 || len(lines) != 2 {
+		t.Errorf("sent %d lines in %d posts before flushing, want one full batch", len(lines), posts)
+	}
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if posts, lines := f.written(); posts != 2 || len(lines) != 3 {
+		t.Errorf("sent %d lines in %d posts after flushing, want 3 in 2", len(lines), posts)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	f := newFakeInflux(t)
+	f.unavailable = 2
+	policy := envoy.DefaultRetryPolicy
+	policy.InitialBackoff, policy.MaxBackoff = time.Millisecond, time.Millisecond
+	w := NewWriter(f.URL, WithRetryPolicy(policy), WithBatchSize(1))
+
+	if err := w.Write(context.Background(), update("production", 250)); err != nil {
+		t.Fatal(err)
+	}
+	if posts, lines := f.written(); posts != 3 || len(lines) != 1 {
+		t.Errorf("sent %d lines in %d posts, want 1 after two retries", len(lines), posts)
+	}
+}
+
+func TestRejectKeepsGoodLines(t *testing.T) {
+	f := newFakeInflux(t)
+	w := NewWriter(f.URL, WithRetryPolicy(envoy.NoRetry), WithBatchSize(100), WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	for _, source := range []string{"production", "bad", "production", "production", "production"} {
+		if err := w.Write(ctx, update(source, 250)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var werr *WriteError
+	if err := w.Flush(ctx); !errors.As(err, &werr) || werr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v, want the rejection", err)
+	}
+	if _, lines := f.written(); len(lines) != 4 {
+		t.Errorf("wrote %d lines, want the 4 good ones", len(lines))
+	}
+	if err := w.Flush(ctx); err != nil {
+		t.Errorf("the rejected point is still buffered: %v", err)
+	}
+}
+
+func TestUnauthorizedKeepsPoints(t *testing.T) {
+	f := newFakeInflux(t)
+	f.unauthorized = true
+	w := NewWriter(f.URL, WithBatchSize(100), WithFlushInterval(time.Hour))
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		if err := w.Write(ctx, update("production", envoy.Watts(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var werr *WriteError
+	if err := w.Flush(ctx); !errors.As(err, &werr) || werr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401", err)
+	}
+	if posts, _ := f.written(); posts != 1 {
+		t.Errorf("sent %d posts, want 1 without retrying or splitting the batch", posts)
+	}
+
+	f.mu.Lock()
+	f.unauthorized = false
+	f.mu.Unlock()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, lines := f.written(); len(lines) != 4 {
+		t.Errorf("wrote %d lines once authorized, want the 4 buffered ones", len(lines))
+	}
+}
@@ -0,0 +1,102 @@
+package envoy
+
+import (
+	"context"
+	"expvar"
+	"sync"
+)
+
+// expvarClients holds the Client behind each expvar published by WithExpvar, and expvarNames every name WithExpvar
+// has published. expvar cannot unpublish a variable, so a Client created again under the same name takes the place
+// of the earlier one, and the variable is null while no Client holds it.
+var (
+	expvarMu      sync.Mutex
+	expvarClients = make(map[string]*Client)
+	expvarNames   = make(map[string]bool)
+)
+
+// WithExpvar publishes the latest readings and the request counters of the Client as the expvar *name*, e.g. for a
+// quick look through /debug/vars. The readings are those of the last Production or Readings call, so they stay empty
+// until something polls the Envoy. A later Client with the same *name* replaces this one, e.g. after a
+// reconfiguration, and Close withdraws it. A *name* published other than by WithExpvar is left alone, and the Client
+// logs a warning instead.
+func WithExpvar(name string) Option {
+	return func(c *Client) {
+		c.expvars = append(c.expvars, name)
+	}
+}
+
+// publishExpvars publishes the Client under the names given to WithExpvar. It runs once the options are applied, so
+// conflicts reach the Client's logger.
+func (c *Client) publishExpvars() {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	ctx := context.Background()
+	for _, name := range c.expvars {
+		switch {
+		case expvarNames[name]:
+			if prev := expvarClients[name]; prev != nil && prev != c {
+				c.logger.DebugContext(ctx, "replacing the client published as an expvar", "name", name,
+					"previous", prev.address)
+			}
+		case expvar.Get(name) != nil:
+			c.logger.WarnContext(ctx, "not publishing the client as an expvar, the name is already in use", "name", name)
+			continue
+		default:
+			expvar.Publish(name, expvar.Func(func() interface{} {
+				expvarMu.Lock()
+				c := expvarClients[name]
+				expvarMu.Unlock()
+				if c == nil {
+					return nil
+				}
+				return c.expvarValue()
+			}))
+			expvarNames[name] = true
+		}
+		expvarClients[name] = c
+	}
+}
+
+// Close withdraws the Client from the expvars it holds, which read null until another Client takes them, and closes
+// its idle connections
+func (c *Client) Close() error {
+	expvarMu.Lock()
+	for _, name := range c.expvars {
+		if expvarClients[name] == c {
+			delete(expvarClients, name)
+		}
+	}
+	expvarMu.Unlock()
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// recordLatest keeps *r* as the latest readings. Unless *battery* is set, the battery readings of the previous ones
+// are kept, since production.json alone does not report them all.
+func (c *Client) recordLatest(r Readings, battery bool) {
+	c.latestMu.Lock()
+	defer c.latestMu.Unlock()
+	if !battery {
+		r.Battery, r.SOC, r.HasBattery = c.latest.Battery, c.latest.SOC, c.latest.HasBattery
+	}
+	c.latest = r
+}
+
+func (c *Client) expvarValue() interface{} {
+	c.latestMu.Lock()
+	latest := c.latest
+	c.latestMu.Unlock()
+	s := c.Stats()
+	v := map[string]interface{}{
+		"requests":        s.Requests,
+		"retries":         s.Retries,
+		"failures":        s.Failures,
+		"error_responses": s.ErrorResponses,
+		"request_seconds": s.Duration.Seconds(),
+	}
+	if !latest.Time.IsZero() {
+		v["readings"] = sampleOf(Update{}, latest)
+	}
+	return v
+}
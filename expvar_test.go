@@ -0,0 +1,68 @@
+package envoy
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithExpvarReplaces(t *testing.T) {
+	NewClient("192.0.2.1", WithExpvar("envoy_test_replace"))
+	second := NewClient("192.0.2.2", WithExpvar("envoy_test_replace"))
+	second.recordLatest(Readings{Time: time.Now(), Production: 250}, false)
+
+	var v struct {
+		Readings struct {
+			Production float64
+		}
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("envoy_test_replace").String()), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Readings.Production != 250 {
+		t.Errorf("published %+v, want the second Client's readings", v)
+	}
+}
+
+func TestWithExpvarLeavesOthers(t *testing.T) {
+	other := expvar.NewString("envoy_test_other")
+	other.Set("mine")
+	var log bytes.Buffer
+	NewClient("192.0.2.1", WithExpvar("envoy_test_other"), WithLogger(slog.New(slog.NewTextHandler(&log, nil))))
+	if got := expvar.Get("envoy_test_other"); got != other {
+		t.Errorf("expvar replaced by %v", got)
+	}
+	if !strings.Contains(log.String(), "already in use") || !strings.Contains(log.String(), "envoy_test_other") {
+		t.Errorf("logged %q, want a warning about the name", log.String())
+	}
+}
+
+func TestCloseWithdrawsExpvar(t *testing.T) {
+	first := NewClient("192.0.2.1", WithExpvar("envoy_test_close"))
+	second := NewClient("192.0.2.2", WithExpvar("envoy_test_close"))
+	// closing a replaced Client leaves its successor published
+	first.Close()
+	if got := expvar.Get("envoy_test_close").String(); got == "null" {
+		t.Errorf("closing the replaced client withdrew its successor")
+	}
+	second.Close()
+	if got := expvar.Get("envoy_test_close").String(); got != "null" {
+		t.Errorf("published %s after closing, want null", got)
+	}
+	expvarMu.Lock()
+	_, held := expvarClients["envoy_test_close"]
+	expvarMu.Unlock()
+	if held {
+		t.Error("the closed client is still held")
+	}
+
+	third := NewClient("192.0.2.3", WithExpvar("envoy_test_close"))
+	defer third.Close()
+	if got := expvar.Get("envoy_test_close").String(); got == "null" {
+		t.Error("a new client did not take the name over")
+	}
+}
@@ -0,0 +1,210 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// generatorConfigPath serves the generator settings of an IQ System Controller
+	generatorConfigPath = "/ivp/ss/gen_config"
+	// generatorModePath serves the generator start mode
+	generatorModePath = "/ivp/ss/gen_mode"
+)
+
+// Generator start modes
+const (
+	// GeneratorOff keeps the generator stopped
+	GeneratorOff = "off"
+	// GeneratorOn runs the generator
+	GeneratorOn = "on"
+	// GeneratorAuto starts and stops the generator at the configured battery state of charge
+	GeneratorAuto = "auto"
+)
+
+// GeneratorConfig is the generator configuration of an IQ System Controller. The fields carry no omitempty so they
+// can be written back.
+type GeneratorConfig struct {
+	// Type is the generator's fuel or connection type as configured by the installer
+	Type string `json:"gen_type"`
+	// RatedPowerW is the generator's rated output, in W
+	RatedPowerW int `json:"rated_power"`
+	// StartSOC is the battery state of charge, in percent, at which GeneratorAuto starts the generator
+	StartSOC int `json:"low_soc"`
+	// StopSOC is the battery state of charge, in percent, at which GeneratorAuto stops the generator
+	StopSOC int `json:"high_soc"`
+	// ExerciseEnabled reports whether the generator is run periodically to keep it in working order
+	ExerciseEnabled bool `json:"exercise_enabled"`
+}
+
+// Validate checks that the SOC thresholds are percentages and that the generator stops above where it starts
+func (g GeneratorConfig) Validate() error {
+	if g.StartSOC < 0 || g.StopSOC > 100 || g.StartSOC >= g.StopSOC {
+		return fmt.Errorf("invalid generator thresholds: start %d%%, stop %d%%", g.StartSOC, g.StopSOC)
+	}
+	return nil
+}
+
+type generatorConfig struct {
+	Config json.RawMessage `json:"gen_config"`
+}
+
+type generatorMode struct {
+	Mode string `json:"gen_mode"`
+}
+
+// GeneratorConfig returns the generator configuration. Systems without an IQ System Controller return a
+// *NotSupportedError for CapabilityEnpower.
+func (c *Client) GeneratorConfig(ctx context.Context) (GeneratorConfig, error) {
+	var config GeneratorConfig
+	var doc generatorConfig
+	if err := c.getFeature(ctx, CapabilityEnpower, generatorConfigPath, &doc); err != nil {
+		return config, err
+	}
+	err := json.Unmarshal(doc.Config, &config)
+	return config, err
+}
+
+// UpdateGeneratorConfig changes the generator configuration, preserving fields the package does not model. The
+// action is subject to the Client's ConfirmFunc.
+func (c *Client) UpdateGeneratorConfig(ctx context.Context, config GeneratorConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	var doc generatorConfig
+	if err := c.getFeature(ctx, CapabilityEnpower, generatorConfigPath, &doc); err != nil {
+		return err
+	}
+	var raw rawObject
+	if err := json.Unmarshal(doc.Config, &raw); err != nil {
+		return fmt.Errorf("decoding generator config: %w", err)
+	}
+	if err := mergeInto(raw, config); err != nil {
+		return err
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("set generator start %d%% stop %d%%", config.StartSOC, config.StopSOC)); err != nil {
+		return err
+	}
+	var err error
+	if doc.Config, err = json.Marshal(raw); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, generatorConfigPath, doc, nil)
+}
+
+// GeneratorMode returns the generator start mode, one of GeneratorOff, GeneratorOn or GeneratorAuto
+func (c *Client) GeneratorMode(ctx context.Context) (string, error) {
+	var mode generatorMode
+	err := c.getFeature(ctx, CapabilityEnpower, generatorModePath, &mode)
+	return mode.Mode, err
+}
+
+// SetGeneratorMode sets the generator start mode, then reads it back, returning ErrNotApplied if it did not take.
+// The action is subject to the Client's ConfirmFunc.
+func (c *Client) SetGeneratorMode(ctx context.Context, mode string) error {
+	switch mode {
+	case GeneratorOff, GeneratorOn, GeneratorAuto:
+	default:
+		return fmt.Errorf("unknown generator mode %q", mode)
+	}
+	// fail with a *NotSupportedError before asking for confirmation if there is no generator to control
+	if _, err := c.GeneratorMode(ctx); err != nil {
+		return err
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("set generator mode %s", mode)); err != nil {
+		return err
+	}
+	if err := c.call(ctx, http.MethodPut, generatorModePath, generatorMode{mode}, nil); err != nil {
+		return err
+	}
+	applied, err := c.GeneratorMode(ctx)
+	if err != nil {
+		return err
+	}
+	if applied != mode {
+		return fmt.Errorf("%w: generator mode is %s", ErrNotApplied, applied)
+	}
+	return nil
+}
+
+// generatorStartTimeout bounds StartGenerator and StopGenerator unless the caller sets a deadline
+const generatorStartTimeout = 3 * time.Minute
+
+// DefaultGeneratorPollInterval is how often StartGenerator and StopGenerator check the generator's state unless
+// WithGeneratorPollInterval says otherwise
+const DefaultGeneratorPollInterval = 5 * time.Second
+
+// WithGeneratorPollInterval sets how often StartGenerator and StopGenerator check the generator's state. A zero or
+// negative interval keeps DefaultGeneratorPollInterval.
+func WithGeneratorPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.generatorPoll = interval
+		}
+	}
+}
+
+// GeneratorStatus is the state of the generator from /ivp/ensemble/generator
+type GeneratorStatus struct {
+	// AdminState is the commanded state, on or off
+	AdminState string `json:"admin_state,omitempty"`
+	// OperState is the actual state, e.g. running or off
+	OperState string `json:"oper_state,omitempty"`
+	// AdminMode is the start mode, one of GeneratorOff, GeneratorOn or GeneratorAuto
+	AdminMode string `json:"admin_mode,omitempty"`
+}
+
+// Running reports whether the generator is running
+func (g GeneratorStatus) Running() bool {
+	return g.OperState == "on" || g.OperState == "running"
+}
+
+// GeneratorStatus returns the state of the generator. Systems without an IQ System Controller return a
+// *NotSupportedError for CapabilityEnpower.
+func (c *Client) GeneratorStatus(ctx context.Context) (GeneratorStatus, error) {
+	var status GeneratorStatus
+	err := c.getFeature(ctx, CapabilityEnpower, "/ivp/ensemble/generator", &status)
+	return status, err
+}
+
+// StartGenerator switches the generator to GeneratorOn and polls until it is running, e.g. to exercise it. It
+// returns ErrNotApplied if the generator has not started by the deadline, three minutes unless *ctx* sets one. The
+// action is subject to the Client's ConfirmFunc.
+func (c *Client) StartGenerator(ctx context.Context) error {
+	return c.runGenerator(ctx, GeneratorOn, true)
+}
+
+// StopGenerator switches the generator to GeneratorOff and polls until it has stopped. Use SetGeneratorMode to
+// return it to GeneratorAuto afterwards. The action is subject to the Client's ConfirmFunc.
+func (c *Client) StopGenerator(ctx context.Context) error {
+	return c.runGenerator(ctx, GeneratorOff, false)
+}
+
+func (c *Client) runGenerator(ctx context.Context, mode string, running bool) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, generatorStartTimeout)
+		defer cancel()
+	}
+	if err := c.SetGeneratorMode(ctx, mode); err != nil {
+		return err
+	}
+	for {
+		status, err := c.GeneratorStatus(ctx)
+		if err != nil && ctx.Err() != nil {
+			return fmt.Errorf("%w: generator did not reach %s: %w", ErrNotApplied, mode, err)
+		}
+		if err != nil {
+			return err
+		}
+		if status.Running() == running {
+			return nil
+		}
+		if err := sleep(ctx, c.generatorPoll); err != nil {
+			return fmt.Errorf("%w: generator is %s: %w", ErrNotApplied, status.OperState, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeGenerator serves the generator endpoints of an IQ System Controller on *f*
+type fakeGenerator struct {
+	mu   sync.Mutex
+	mode string
+	// startPolls is how many status polls the generator takes to start once switched on
+	startPolls int
+	polls      int
+}
+
+func (g *fakeGenerator) install(f *fakeEnvoy) {
+	f.handleJSON(generatorConfigPath,
+		`{"gen_config":{"gen_type":"diesel","rated_power":8000,"low_soc":20,"high_soc":80,"exercise_enabled":true,"vendor":"x"}}`)
+	f.handle(generatorModePath, func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if r.Method == http.MethodPut {
+			var m generatorMode
+			json.NewDecoder(r.Body).Decode(&m)
+			g.mode, g.polls = m.Mode, 0
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(generatorMode{g.mode})
+	})
+	f.handle("/ivp/ensemble/generator", func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.polls++
+		status := GeneratorStatus{AdminState: "off", OperState: "off", AdminMode: g.mode}
+		if g.mode == GeneratorOn {
+			status.AdminState = "on"
+			if g.polls > g.startPolls {
+				status.OperState = "running"
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+}
+
+func TestGeneratorConfig(t *testing.T) {
+	f := newFakeEnvoy(t)
+	(&fakeGenerator{mode: GeneratorAuto}).install(f)
+	c := f.client()
+	ctx := context.Background()
+
+	config, err := c.GeneratorConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.RatedPowerW != 8000 || config.StartSOC != 20 || config.StopSOC != 80 {
+		t.Errorf("got %+v", config)
+	}
+	if mode, err := c.GeneratorMode(ctx); err != nil || mode != GeneratorAuto {
+		t.Errorf("got mode %q, %v, want auto", mode, err)
+	}
+	if err := c.SetGeneratorMode(ctx, GeneratorOff); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratorNotSupported(t *testing.T) {
+	c := newFakeEnvoy(t).client()
+	if _, err := c.GeneratorConfig(context.Background()); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("got %v, want ErrNotSupported", err)
+	}
+	if err := c.SetGeneratorMode(context.Background(), GeneratorOn); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("got %v, want ErrNotSupported", err)
+	}
+}
+
+func TestStartGenerator(t *testing.T) {
+	f := newFakeEnvoy(t)
+	g := &fakeGenerator{mode: GeneratorAuto, startPolls: 2}
+	g.install(f)
+	var confirmed []string
+	c := f.client(WithGeneratorPollInterval(time.Millisecond), WithConfirm(func(ctx context.Context, action string) bool {
+		confirmed = append(confirmed, action)
+		return true
+	}))
+	ctx := context.Background()
+
+	if err := c.StartGenerator(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 1 {
+		t.Errorf("confirmed %q, want one action", confirmed)
+	}
+	status, err := c.GeneratorStatus(ctx)
+	if err != nil || !status.Running() {
+		t.Fatalf("got %+v, %v, want the generator running", status, err)
+	}
+	if g.polls < 3 {
+		t.Errorf("polled the status %d times, want StartGenerator to wait for the generator to start", g.polls)
+	}
+	if err := c.StopGenerator(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartGeneratorNotApplied(t *testing.T) {
+	f := newFakeEnvoy(t)
+	(&fakeGenerator{mode: GeneratorAuto, startPolls: 1 << 30}).install(f)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := f.client(WithGeneratorPollInterval(time.Millisecond)).StartGenerator(ctx); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("got %v, want ErrNotApplied", err)
+	}
+}
@@ -0,0 +1,121 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GridProfile is a grid profile available on the Envoy
+type GridProfile struct {
+	ID      string `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// GridProfiles is the response of the installer AGF (advanced grid functions) endpoint
+type GridProfiles struct {
+	// SelectedProfile is the active profile, as name:version
+	SelectedProfile string `json:"selected_profile,omitempty"`
+	// ProfileStatus reports whether the active profile has propagated to the inverters, e.g. success or pending
+	ProfileStatus string        `json:"profile_status,omitempty"`
+	Profiles      []GridProfile `json:"profiles,omitempty"`
+}
+
+// Active returns the name and version of the active profile
+func (g GridProfiles) Active() (name, version string) {
+	i := strings.LastIndex(g.SelectedProfile, ":")
+	if i < 0 {
+		return g.SelectedProfile, ""
+	}
+	return g.SelectedProfile[:i], g.SelectedProfile[i+1:]
+}
+
+// GridProfiles returns the available grid profiles and the active one. It requires an installer token; an owner
+// token gets an error matching ErrForbidden.
+func (c *Client) GridProfiles(ctx context.Context) (GridProfiles, error) {
+	var profiles GridProfiles
+	err := c.get(ctx, "/installer/agf/index.json?simplified=true", &profiles)
+	return profiles, err
+}
+
+// DefaultGridProfilePollInterval is how often SetGridProfile checks whether the inverters have applied the new
+// profile unless WithGridProfilePollInterval says otherwise
+const DefaultGridProfilePollInterval = 15 * time.Second
+
+// WithGridProfilePollInterval sets how often SetGridProfile checks whether the inverters have applied the new
+// profile. A zero or negative interval keeps DefaultGridProfilePollInterval.
+func WithGridProfilePollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.gridProfilePoll = interval
+		}
+	}
+}
+
+// InverterProfileStatus is the grid profile state of one inverter
+type InverterProfileStatus struct {
+	Profile string `json:"profile,omitempty"`
+	// Status is success once the inverter runs the profile
+	Status string `json:"status,omitempty"`
+}
+
+// GridProfileProgress reports how far a new grid profile has propagated
+type GridProfileProgress struct {
+	Total int
+	Done  int
+	// Pending lists the serial numbers of the inverters that have not applied the profile yet
+	Pending []string
+}
+
+// InverterProfiles returns the grid profile state of every inverter. It requires an installer token.
+func (c *Client) InverterProfiles(ctx context.Context) (map[string]InverterProfileStatus, error) {
+	var status map[string]InverterProfileStatus
+	err := c.get(ctx, "/installer/agf/inverters_status.json", &status)
+	return status, err
+}
+
+// SetGridProfile activates the grid profile *name* at *version*, then polls until every inverter has applied it,
+// calling *progress*, if not nil, after each poll. It returns once propagation completes or *ctx* is done. It requires
+// an installer token, and the action is subject to the Client's ConfirmFunc.
+func (c *Client) SetGridProfile(ctx context.Context, name, version string, progress func(GridProfileProgress)) error {
+	selected := name + ":" + version
+	if err := c.confirm(ctx, fmt.Sprintf("set grid profile %s", selected)); err != nil {
+		return err
+	}
+	body := struct {
+		SelectedProfile string `json:"selected_profile"`
+	}{selected}
+	if err := c.call(ctx, http.MethodPut, "/installer/agf/set_profile.json", body, nil); err != nil {
+		return err
+	}
+
+	for {
+		status, err := c.InverterProfiles(ctx)
+		if err != nil {
+			return err
+		}
+		p := GridProfileProgress{Total: len(status)}
+		for serial, s := range status {
+			if s.Profile == selected && s.Status == "success" {
+				p.Done++
+			} else {
+				p.Pending = append(p.Pending, serial)
+			}
+		}
+		sort.Strings(p.Pending)
+		if progress != nil {
+			progress(p)
+		}
+		// right after the change the Envoy may not list any inverters yet, which is not the same as all done
+		if p.Total > 0 && len(p.Pending) == 0 {
+			return nil
+		}
+		if err := sleep(ctx, c.gridProfilePoll); err != nil {
+			return err
+		}
+	}
+}
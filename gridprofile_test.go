@@ -0,0 +1,37 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetGridProfileWaitsForInverters(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handle("/installer/agf/set_profile.json", func(w http.ResponseWriter, r *http.Request) {})
+	var polls atomic.Int32
+	f.handle("/installer/agf/inverters_status.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if polls.Add(1) < 3 {
+			// the Envoy has not listed the inverters yet
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"1":{"profile":"IEEE1547:1.2.3","status":"success"}}`))
+	})
+
+	var last GridProfileProgress
+	c := f.client(WithGridProfilePollInterval(time.Millisecond))
+	err := c.SetGridProfile(context.Background(), "IEEE1547", "1.2.3", func(p GridProfileProgress) { last = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := polls.Load(); n != 3 {
+		t.Errorf("polled %d times, want 3", n)
+	}
+	if last.Total != 1 || last.Done != 1 {
+		t.Errorf("got final progress %+v, want 1 of 1 done", last)
+	}
+}
@@ -0,0 +1,39 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSerialMismatch is returned by Health if the Envoy reports a different serial number than expected
+var ErrSerialMismatch = errors.New("serial number mismatch")
+
+// HealthStatus describes the outcome of a health check
+type HealthStatus struct {
+	// Serial is the serial number the Envoy reported
+	Serial string
+	// RoundTrip is how long the Envoy took to answer
+	RoundTrip time.Duration
+}
+
+// Health checks that the Envoy is reachable by fetching its unauthenticated /info endpoint, and that it reports
+// serial number *serial*, unless *serial* is empty. No token is required.
+func (c *Client) Health(ctx context.Context, serial string) (HealthStatus, error) {
+	info, rtt, err := c.fetchInfo(ctx)
+	status := HealthStatus{Serial: info.Device.SerialNumber, RoundTrip: rtt}
+	if err != nil {
+		return status, err
+	}
+	if serial != "" && status.Serial != serial {
+		return status, fmt.Errorf("%w: expected %s, got %s", ErrSerialMismatch, serial, status.Serial)
+	}
+	return status, nil
+}
+
+// Ping checks that the Envoy is reachable and returns the round-trip time. No token is required.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	status, err := c.Health(ctx, "")
+	return status.RoundTrip, err
+}
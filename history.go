@@ -0,0 +1,148 @@
+package envoy
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults of NewHistory
+const (
+	DefaultHistoryRetention  = 24 * time.Hour
+	DefaultHistoryResolution = 5 * time.Second
+)
+
+// Point is a value recorded by a History
+type Point[T any] struct {
+	Time  time.Time
+	Value T
+}
+
+// History is a bounded in-memory time series of the values of one type, e.g. the Readings or Production of a Poller
+// source, so recent history can be served without an external database. The points live in a ring buffer sized for
+// the retention at the resolution, so memory use is fixed.
+type History[T any] struct {
+	retention  time.Duration
+	resolution time.Duration
+
+	mu     sync.RWMutex
+	points []Point[T]
+	// start is the index of the oldest point and n the number of points
+	start, n int
+}
+
+// NewHistory creates a History keeping *retention* of points, one per *resolution*, e.g. 24h at 5s. Zero
+// values mean DefaultHistoryRetention and DefaultHistoryResolution.
+func NewHistory[T any](retention, resolution time.Duration) *History[T] {
+	if retention <= 0 {
+		retention = DefaultHistoryRetention
+	}
+	if resolution <= 0 {
+		resolution = DefaultHistoryResolution
+	}
+	return &History[T]{
+		retention:  retention,
+		resolution: resolution,
+		points:     make([]Point[T], int(retention/resolution)+1),
+	}
+}
+
+// Observe records the value of *u* if it is a T, e.g. from a Poller subscription. Other values and failed polls are
+// ignored.
+func (h *History[T]) Observe(u Update) {
+	if v, ok := u.Value.(T); ok && u.Err == nil {
+		h.Add(u.Time, v)
+	}
+}
+
+// Record subscribes the History to the Updates of the Poller's source named *source*, or of every source if
+// *source* is empty, until the Poller stops
+func (h *History[T]) Record(p *Poller, source string) {
+	updates := p.Subscribe(16)
+	go func() {
+		for u := range updates {
+			if source == "" || u.Source == source {
+				h.Observe(u)
+			}
+		}
+	}()
+}
+
+// Add records *v* at *t*. The History keeps one point per resolution-long interval, so a point in the same interval
+// as the latest one replaces it, while points older than the latest one or out of the retention are dropped.
+func (h *History[T]) Add(t time.Time, v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.n > 0 {
+		last := (h.start + h.n - 1) % len(h.points)
+		switch latest := h.points[last].Time; {
+		case t.Before(latest):
+			return
+		case t.Truncate(h.resolution).Equal(latest.Truncate(h.resolution)):
+			// polls jitter around the resolution, so keep the freshest value of each interval
+			h.points[last] = Point[T]{Time: t, Value: v}
+			return
+		}
+	}
+	if h.n == len(h.points) {
+		h.start = (h.start + 1) % len(h.points)
+		h.n--
+	}
+	h.points[(h.start+h.n)%len(h.points)] = Point[T]{Time: t, Value: v}
+	h.n++
+	for h.n > 0 && t.Sub(h.at(0).Time) > h.retention {
+		h.points[h.start] = Point[T]{}
+		h.start = (h.start + 1) % len(h.points)
+		h.n--
+	}
+}
+
+// at returns the *i*th oldest point; the caller holds mu
+func (h *History[T]) at(i int) Point[T] {
+	return h.points[(h.start+i)%len(h.points)]
+}
+
+// search returns the index of the first point after *t*; the caller holds mu
+func (h *History[T]) search(t time.Time) int {
+	return sort.Search(h.n, func(i int) bool { return h.at(i).Time.After(t) })
+}
+
+// Latest returns the most recent point
+func (h *History[T]) Latest() (Point[T], bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.n == 0 {
+		return Point[T]{}, false
+	}
+	return h.at(h.n - 1), true
+}
+
+// At returns the point in effect at *t*, the latest recorded at or before it
+func (h *History[T]) At(t time.Time) (Point[T], bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	i := h.search(t)
+	if i == 0 {
+		return Point[T]{}, false
+	}
+	return h.at(i - 1), true
+}
+
+// Range returns the points recorded from *from* up to but excluding *to*, oldest first
+func (h *History[T]) Range(from, to time.Time) []Point[T] {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	i := sort.Search(h.n, func(i int) bool { return !h.at(i).Time.Before(from) })
+	var points []Point[T]
+	for ; i < h.n && h.at(i).Time.Before(to); i++ {
+		points = append(points, h.at(i))
+	}
+	return points
+}
+
+// Len returns the number of points held
+func (h *History[T]) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.n
+}
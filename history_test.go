@@ -0,0 +1,62 @@
+package envoy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHistoryJitter(t *testing.T) {
+	h := NewHistory[int](time.Hour, 5*time.Second)
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	// polls slightly under the resolution apart each land in an interval of their own
+	for i, offset := range []time.Duration{200 * time.Millisecond, 5100 * time.Millisecond, 10 * time.Second, 15100 * time.Millisecond} {
+		h.Add(base.Add(offset), i)
+	}
+	if n := h.Len(); n != 4 {
+		t.Fatalf("kept %d jittered points, want 4", n)
+	}
+
+	h.Add(base.Add(15200*time.Millisecond), 10)
+	if p, _ := h.Latest(); p.Value != 10 || h.Len() != 4 {
+		t.Errorf("latest %+v of %d points, want the second point of the interval to replace the first", p, h.Len())
+	}
+	h.Add(base.Add(time.Second), 20)
+	if p, _ := h.Latest(); p.Value != 10 || h.Len() != 4 {
+		t.Errorf("latest %+v of %d points, want the out-of-order point dropped", p, h.Len())
+	}
+}
+
+func TestHistoryRecord(t *testing.T) {
+	n := 0
+	count := NewSource("count", func(c *Client, ctx context.Context) (int, error) {
+		n++
+		return n, nil
+	})
+	other := NewSource("other", func(c *Client, ctx context.Context) (int, error) {
+		return -1, nil
+	})
+	p := NewPoller(nil, 10*time.Millisecond, count, other)
+	h := NewHistory[int](time.Hour, time.Millisecond)
+	h.Record(p, "count")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for h.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-p.Done()
+	points := h.Range(time.Time{}, time.Now().Add(time.Hour))
+	if len(points) < 2 {
+		t.Fatalf("recorded %d points, want at least 2", len(points))
+	}
+	for _, point := range points {
+		if point.Value < 0 {
+			t.Errorf("recorded %+v from another source", point)
+		}
+	}
+}
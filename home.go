@@ -0,0 +1,105 @@
+package envoy
+
+import "context"
+
+// Home is the system summary from /home.json, the cheapest endpoint for dashboards
+type Home struct {
+	// SoftwareBuildEpoch is the firmware build date
+	SoftwareBuildEpoch Timestamp   `json:"software_build_epoch,omitempty"`
+	IsNonvoy           bool        `json:"is_nonvoy,omitempty"`
+	DBSize             int         `json:"db_size,omitempty"`
+	DBPercentFull      int         `json:"db_percent_full,omitempty"`
+	Timezone           string      `json:"timezone,omitempty"`
+	CurrentDate        string      `json:"current_date,omitempty"`
+	CurrentTime        string      `json:"current_time,omitempty"`
+	Network            HomeNetwork `json:"network,omitempty"`
+	// Tariff is the configured tariff type, e.g. single_rate or tou
+	Tariff             string               `json:"tariff,omitempty"`
+	Comm               HomeComm             `json:"comm,omitempty"`
+	Alerts             []HomeAlert          `json:"alerts,omitempty"`
+	UpdateStatus       string               `json:"update_status,omitempty"`
+	WirelessConnection []WirelessConnection `json:"wireless_connection,omitempty"`
+	Enpower            *HomeEnpower         `json:"enpower,omitempty"`
+}
+
+// HomeNetwork describes the Envoy's network connectivity
+type HomeNetwork struct {
+	WebComm                 bool            `json:"web_comm,omitempty"`
+	EverReportedToEnlighten bool            `json:"ever_reported_to_enlighten,omitempty"`
+	LastEnlightenReportTime int64           `json:"last_enlighten_report_time,omitempty"`
+	PrimaryInterface        string          `json:"primary_interface,omitempty"`
+	Interfaces              []HomeInterface `json:"interfaces,omitempty"`
+}
+
+// HomeInterface describes one network interface of the Envoy
+type HomeInterface struct {
+	// Type is ethernet, wifi or cellular
+	Type              string `json:"type,omitempty"`
+	Interface         string `json:"interface,omitempty"`
+	MAC               string `json:"mac,omitempty"`
+	DHCP              bool   `json:"dhcp,omitempty"`
+	IP                string `json:"ip,omitempty"`
+	SignalStrength    int    `json:"signal_strength,omitempty"`
+	SignalStrengthMax int    `json:"signal_strength_max,omitempty"`
+	Carrier           bool   `json:"carrier,omitempty"`
+	Supported         bool   `json:"supported,omitempty"`
+	Present           bool   `json:"present,omitempty"`
+	Configured        bool   `json:"configured,omitempty"`
+	Status            string `json:"status,omitempty"`
+}
+
+// CommLevel is the count of devices of one class and the quality of the Envoy's communication with them, from 0 to 5
+type CommLevel struct {
+	Num   int `json:"num,omitempty"`
+	Level int `json:"level,omitempty"`
+}
+
+// EnchargeCommLevel is the communication quality with Encharge batteries, which use two radios
+type EnchargeCommLevel struct {
+	Num       int `json:"num,omitempty"`
+	Level     int `json:"level,omitempty"`
+	Level24G  int `json:"level_24g,omitempty"`
+	LevelSubG int `json:"level_subg,omitempty"`
+}
+
+// HomeComm is the communication status per device class
+type HomeComm struct {
+	Num   int `json:"num,omitempty"`
+	Level int `json:"level,omitempty"`
+	// PCU are the microinverters
+	PCU CommLevel `json:"pcu,omitempty"`
+	// ACB are the AC batteries
+	ACB CommLevel `json:"acb,omitempty"`
+	// NSRB are the network system relays (Q Relays)
+	NSRB     CommLevel           `json:"nsrb,omitempty"`
+	ESUB     CommLevel           `json:"esub,omitempty"`
+	Encharge []EnchargeCommLevel `json:"encharge,omitempty"`
+}
+
+// HomeAlert is an alert raised by the Envoy
+type HomeAlert struct {
+	MsgKey string `json:"msg_key,omitempty"`
+	Level  string `json:"level,omitempty"`
+}
+
+// WirelessConnection describes a wireless link such as Bluetooth to the installer app
+type WirelessConnection struct {
+	SignalStrength    int    `json:"signal_strength,omitempty"`
+	SignalStrengthMax int    `json:"signal_strength_max,omitempty"`
+	Type              string `json:"type,omitempty"`
+	Connected         bool   `json:"connected,omitempty"`
+}
+
+// HomeEnpower is the Enpower summary, present on systems with an IQ System Controller
+type HomeEnpower struct {
+	Connected  bool   `json:"connected,omitempty"`
+	GridStatus string `json:"grid_status,omitempty"`
+}
+
+// Home returns the Envoy's system summary: firmware build, network and tariff status, and communication status per
+// device class.
+func (c *Client) Home(ctx context.Context) (Home, error) {
+	var home Home
+	err := c.get(ctx, "/home.json", &home)
+	return home, err
+}
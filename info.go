@@ -0,0 +1,86 @@
+package envoy
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Info is the Envoy's self-description from the /info endpoint
+type Info struct {
+	// Time is the Envoy's clock, in seconds since the epoch
+	Time      int64      `xml:"time"`
+	Device    InfoDevice `xml:"device"`
+	WebTokens bool       `xml:"web-tokens"`
+	Packages  []Package  `xml:"package"`
+	BuildInfo BuildInfo  `xml:"build_info"`
+}
+
+// InfoDevice identifies the Envoy hardware and the software it runs
+type InfoDevice struct {
+	SerialNumber string `xml:"sn"`
+	PartNumber   string `xml:"pn"`
+	// Software is the firmware version, e.g. D7.0.88
+	Software string `xml:"software"`
+	EUAID    string `xml:"euaid"`
+	SeqNum   int    `xml:"seqnum"`
+	APIVer   int    `xml:"apiver"`
+	// IMeter reports whether the Envoy has integrated revenue-grade meters (an IQ Envoy with CTs)
+	IMeter bool `xml:"imeter"`
+}
+
+// Package describes one of the software packages installed on the Envoy
+type Package struct {
+	Name       string `xml:"name,attr"`
+	PartNumber string `xml:"pn"`
+	Version    string `xml:"version"`
+	Build      string `xml:"build"`
+}
+
+// BuildInfo describes the firmware build. It is only reported by firmware 7.x and later.
+type BuildInfo struct {
+	// BuildTimeGMT is the build date, in seconds since the epoch
+	BuildTimeGMT int64  `xml:"build_time_gmt"`
+	BuildID      string `xml:"build_id"`
+}
+
+// BuildTime returns the firmware build date, or the zero time if the firmware does not report it
+func (b BuildInfo) BuildTime() time.Time {
+	if b.BuildTimeGMT == 0 {
+		return time.Time{}
+	}
+	return time.Unix(b.BuildTimeGMT, 0)
+}
+
+// Info returns the Envoy's serial number, part number and firmware details. No token is required.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	info, _, err := c.fetchInfo(ctx)
+	return info, err
+}
+
+// fetchInfo fetches /info without logging in, since it is available without authentication on every firmware,
+// returning the round-trip time along with it.
+func (c *Client) fetchInfo(ctx context.Context) (Info, time.Duration, error) {
+	var info Info
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/info", c.proto, c.address), nil)
+	if err != nil {
+		return info, 0, err
+	}
+	start := time.Now()
+	resp, err := c.do(req)
+	if err != nil {
+		return info, 0, err
+	}
+	defer resp.Body.Close()
+	rtt := time.Since(start)
+	if resp.StatusCode != http.StatusOK {
+		return info, rtt, newAPIError(resp)
+	}
+	err = xml.NewDecoder(resp.Body).Decode(&info)
+	return info, rtt, err
+}
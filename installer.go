@@ -0,0 +1,63 @@
+package envoy
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"strings"
+)
+
+// installerRealm is the digest realm used by legacy Envoy firmware
+const installerRealm = "enphaseenergy.com"
+
+// InstallerPassword derives the legacy installer password for the Envoy with serial number *serial*, using the
+// algorithm the Enphase installer toolkit applies to the serial.
+func InstallerPassword(serial string) string {
+	return derivePassword(serial, UserInstaller, installerRealm)
+}
+
+func derivePassword(serial, user, realm string) string {
+	sum := md5.Sum([]byte("[e]" + user + "@" + realm + "#" + serial + " EnPhAsE eNeRgY "))
+	hash := hex.EncodeToString(sum[:])
+	countZero := strings.Count(hash, "0")
+	countOne := strings.Count(hash, "1")
+
+	var password strings.Builder
+	// walk the last eight digits of the hash in reverse
+	for i := len(hash) - 1; i >= len(hash)-8; i-- {
+		if countZero == 3 || countZero == 6 || countZero == 9 {
+			countZero--
+		}
+		if countZero > 20 {
+			countZero = 20
+		}
+		if countZero < 0 {
+			countZero = 0
+		}
+		if countOne == 9 || countOne == 15 {
+			countOne--
+		}
+		if countOne > 26 {
+			countOne = 26
+		}
+		if countOne < 0 {
+			countOne = 0
+		}
+		switch cc := hash[i]; cc {
+		case '0':
+			password.WriteByte(byte('f' + countZero))
+			countZero--
+		case '1':
+			password.WriteByte(byte('@' + countOne))
+			countOne--
+		default:
+			password.WriteByte(cc)
+		}
+	}
+	return password.String()
+}
+
+// WithInstallerAuth makes the Client authenticate with digest authentication as the installer user, deriving the
+// password from *serial*. It only applies to firmware older than 7.x.
+func WithInstallerAuth(serial string) Option {
+	return WithDigestAuth(UserInstaller, InstallerPassword(serial))
+}
@@ -0,0 +1,202 @@
+package envoy
+
+import "encoding/json"
+
+// Device types of the groups in inventory.json
+const (
+	DeviceTypePCU  = "PCU"
+	DeviceTypeACB  = "ACB"
+	DeviceTypeNSRB = "NSRB"
+	DeviceTypeESUB = "ESUB"
+)
+
+// Microinverter is a microinverter (PCU) from the inventory
+type Microinverter struct {
+	Device
+	// Phase is the phase the microinverter is wired to, e.g. ph-a, on multi-phase sites
+	Phase string `json:"phase,omitempty"`
+}
+
+// ACBattery is an AC Battery (ACB) from the inventory
+type ACBattery struct {
+	Device
+	// PercentFull is the state of charge
+	PercentFull int `json:"percentFull,omitempty"`
+	// MaxCellTemp is in °C
+	MaxCellTemp int `json:"maxCellTemp,omitempty"`
+	// SleepEnabled is set when the battery sleeps between SleepMinSOC and SleepMaxSOC percent
+	SleepEnabled bool `json:"sleep_enabled,omitempty"`
+	SleepMinSOC  int  `json:"sleep_min_soc,omitempty"`
+	SleepMaxSOC  int  `json:"sleep_max_soc,omitempty"`
+	// ChargeStatus is e.g. idle, charging or discharging
+	ChargeStatus string `json:"charge_status,omitempty"`
+}
+
+// NetworkRelay is a network system relay (NSRB, also known as a Q Relay) from the inventory
+type NetworkRelay struct {
+	Device
+	// Relay is the state of the relay, open or closed
+	Relay string `json:"relay,omitempty"`
+	// ReasonCode and Reason say why the relay is in its state, e.g. "ok" or a grid fault
+	ReasonCode int    `json:"reason_code,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	LineCount  int    `json:"line-count,omitempty"`
+	// Line1Connected, Line2Connected and Line3Connected report whether each line is connected through the relay
+	Line1Connected bool `json:"line1-connected,omitempty"`
+	Line2Connected bool `json:"line2-connected,omitempty"`
+	Line3Connected bool `json:"line3-connected,omitempty"`
+}
+
+// Closed reports whether the relay is closed, connecting the microinverters to the grid
+func (r NetworkRelay) Closed() bool {
+	return r.Relay == "closed"
+}
+
+// Inventory is the content of inventory.json by device class
+type Inventory struct {
+	Microinverters []Microinverter
+	ACBatteries    []ACBattery
+	NetworkRelays  []NetworkRelay
+	// Ensemble holds the Encharge batteries and Enpower system controllers
+	Ensemble []EnsembleDevice
+	// Other holds the groups of device types the package does not model
+	Other []DeviceGroup
+}
+
+// UnmarshalJSON sorts the device groups of inventory.json into their classes
+func (inv *Inventory) UnmarshalJSON(b []byte) error {
+	var groups []struct {
+		Type    string          `json:"type"`
+		Devices json.RawMessage `json:"devices"`
+	}
+	if err := json.Unmarshal(b, &groups); err != nil {
+		return err
+	}
+	*inv = Inventory{}
+	for _, g := range groups {
+		if len(g.Devices) == 0 {
+			continue
+		}
+		var err error
+		switch g.Type {
+		case DeviceTypePCU:
+			err = appendDevices(g.Devices, &inv.Microinverters)
+		case DeviceTypeACB:
+			err = appendDevices(g.Devices, &inv.ACBatteries)
+		case DeviceTypeNSRB:
+			err = appendDevices(g.Devices, &inv.NetworkRelays)
+		case DeviceTypeESUB:
+			err = appendDevices(g.Devices, &inv.Ensemble)
+		default:
+			group := DeviceGroup{Type: g.Type}
+			err = json.Unmarshal(g.Devices, &group.Devices)
+			inv.Other = append(inv.Other, group)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendDevices[T any](raw json.RawMessage, dst *[]T) error {
+	var devices []T
+	if err := json.Unmarshal(raw, &devices); err != nil {
+		return err
+	}
+	*dst = append(*dst, devices...)
+	return nil
+}
+
+// Devices returns the fields common to every device in the inventory, whatever its class
+func (inv Inventory) Devices() []Device {
+	var devices []Device
+	for _, d := range inv.Microinverters {
+		devices = append(devices, d.Device)
+	}
+	for _, d := range inv.ACBatteries {
+		devices = append(devices, d.Device)
+	}
+	for _, d := range inv.NetworkRelays {
+		devices = append(devices, d.Device)
+	}
+	for _, d := range inv.Ensemble {
+		devices = append(devices, d.device())
+	}
+	for _, g := range inv.Other {
+		devices = append(devices, g.Devices...)
+	}
+	return devices
+}
+
+// Offline returns the devices that are not communicating with the Envoy, leaving out deleted ones, which never
+// report again. Microinverters stop communicating at night, when they are unpowered.
+func (inv Inventory) Offline() []Device {
+	var offline []Device
+	for _, d := range inv.Devices() {
+		if !d.Communicating && !d.Deleted {
+			offline = append(offline, d)
+		}
+	}
+	return offline
+}
+
+// Active returns a copy of the inventory without the deleted devices
+func (inv Inventory) Active() Inventory {
+	active := Inventory{
+		Microinverters: activeDevices(inv.Microinverters, func(d Microinverter) bool { return d.Deleted }),
+		ACBatteries:    activeDevices(inv.ACBatteries, func(d ACBattery) bool { return d.Deleted }),
+		NetworkRelays:  activeDevices(inv.NetworkRelays, func(d NetworkRelay) bool { return d.Deleted }),
+		Ensemble:       inv.Ensemble,
+	}
+	for _, g := range inv.Other {
+		active.Other = append(active.Other, DeviceGroup{
+			Type:    g.Type,
+			Devices: activeDevices(g.Devices, func(d Device) bool { return d.Deleted }),
+		})
+	}
+	return active
+}
+
+func activeDevices[T any](devices []T, deleted func(T) bool) []T {
+	var active []T
+	for _, d := range devices {
+		if !deleted(d) {
+			active = append(active, d)
+		}
+	}
+	return active
+}
+
+// Find returns the device with serial number *serial*, preferring an active device over a deleted one that had the
+// same serial number
+func (inv Inventory) Find(serial string) (Device, bool) {
+	var found Device
+	ok := false
+	for _, d := range inv.Devices() {
+		if d.SerialNum != serial {
+			continue
+		}
+		if !d.Deleted {
+			return d, true
+		}
+		found, ok = d, true
+	}
+	return found, ok
+}
+
+// device returns the fields an ensemble device shares with the other device classes
+func (d EnsembleDevice) device() Device {
+	return Device{
+		PartNum:        d.PartNum,
+		SerialNum:      d.SerialNum,
+		DeviceStatus:   d.DeviceStatus,
+		LastReportDate: d.LastRptDate,
+		AdminState:     d.AdminState,
+		CreatedDate:    d.CreatedDate,
+		ImgLoadDate:    d.ImgLoadDate,
+		ImgPnumRunning: d.ImgPnumRunning,
+		Communicating:  d.Communicating,
+		Operating:      d.Operating,
+	}
+}
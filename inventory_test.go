@@ -0,0 +1,68 @@
+package envoy
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+// testInventory has an offline microinverter and a deleted one it replaced
+var testInventory = Inventory{Microinverters: []Microinverter{
+	{Device: Device{SerialNum: "1", Communicating: true}},
+	{Device: Device{SerialNum: "2"}},
+	{Device: Device{SerialNum: "3", Deleted: true}},
+}}
+
+func TestOfflineSkipsDeleted(t *testing.T) {
+	offline := testInventory.Offline()
+	if len(offline) != 1 || offline[0].SerialNum != "2" {
+		t.Errorf("got %+v, want only device 2", offline)
+	}
+}
+
+func TestWatcherSkipsDeleted(t *testing.T) {
+	w := Watcher{Daylight: func(time.Time) bool { return true }}
+	now := time.Now()
+	w.Observe(Update{Source: "inventory", Time: now, Value: testInventory})
+
+	inv := Inventory{Microinverters: []Microinverter{
+		{Device: Device{SerialNum: "1"}},
+		{Device: Device{SerialNum: "2"}},
+		{Device: Device{SerialNum: "3", Deleted: true, Communicating: true}},
+	}}
+	changes := w.Observe(Update{Source: "inventory", Time: now.Add(time.Minute), Value: inv})
+	if len(changes) != 1 || changes[0].Kind != ChangeOffline || changes[0].Serial != "1" {
+		t.Errorf("got %+v, want only device 1 going offline", changes)
+	}
+}
+
+func TestDecodeInventory(t *testing.T) {
+	b, err := os.ReadFile("testdata/inventory.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inv Inventory
+	if err := json.Unmarshal(b, &inv); err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Microinverters) != 2 || len(inv.NetworkRelays) != 1 {
+		t.Fatalf("got %d microinverters and %d relays, want 2 and 1", len(inv.Microinverters), len(inv.NetworkRelays))
+	}
+	m := inv.Microinverters[0]
+	if want := time.Unix(1717243200, 0); !m.LastReportDate.Equal(want) {
+		t.Errorf("last report %v, want %v", m.LastReportDate.Time, want)
+	}
+	if want := time.Unix(1653332998, 0); !m.Installed.Equal(want) {
+		t.Errorf("installed %v, want %v", m.Installed.Time, want)
+	}
+	if m.Phase != "ph-a" || !m.Communicating {
+		t.Errorf("got %+v", m)
+	}
+	if r := inv.NetworkRelays[0]; !r.Closed() || !r.Line3Connected {
+		t.Errorf("relay %+v, want closed on all three lines", r)
+	}
+	if offline := inv.Offline(); len(offline) != 1 || offline[0].SerialNum != "122212345679" {
+		t.Errorf("offline %+v, want the second microinverter", offline)
+	}
+}
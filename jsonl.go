@@ -0,0 +1,78 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// jsonSample is the JSON form of a Sample. Every key is always present, with null for readings the sample does not
+// have, so the schema stays the same from line to line.
+type jsonSample struct {
+	Time        string   `json:"time"`
+	Source      string   `json:"source"`
+	Serial      string   `json:"serial"`
+	Production  float64  `json:"production_w"`
+	Consumption *float64 `json:"consumption_w"`
+	Net         *float64 `json:"net_w"`
+	Battery     *float64 `json:"battery_w"`
+	SOC         *float64 `json:"soc"`
+}
+
+// jsonSampleOf returns the JSON form of *s*, with an RFC 3339 time and null for the readings it does not have
+func jsonSampleOf(s Sample) jsonSample {
+	j := jsonSample{
+		Time:       s.Time.Format(time.RFC3339),
+		Source:     s.Source,
+		Serial:     s.Serial,
+		Production: float64(s.Production),
+	}
+	if s.HasConsumption {
+		consumption, net := float64(s.Consumption), float64(s.Net)
+		j.Consumption, j.Net = &consumption, &net
+	}
+	if s.HasBattery {
+		battery, soc := float64(s.Battery), s.SOC
+		j.Battery, j.SOC = &battery, &soc
+	}
+	return j
+}
+
+// JSONLinesExporter is a Sink writing the Samples of each Update to Out as newline-delimited JSON, one sample per
+// line, for piping into tools such as jq, Vector or Logstash
+type JSONLinesExporter struct {
+	Out io.Writer
+	// Location is the time zone of the timestamps, UTC if nil
+	Location *time.Location
+
+	mu sync.Mutex
+}
+
+// Write writes the Samples of *u*
+func (e *JSONLinesExporter) Write(ctx context.Context, u Update) error {
+	for _, s := range Samples(u) {
+		if err := e.WriteSample(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSample writes *s* as one line
+func (e *JSONLinesExporter) WriteSample(s Sample) error {
+	loc := e.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	s.Time = s.Time.In(loc)
+	b, err := json.Marshal(jsonSampleOf(s))
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.Out.Write(append(b, '\n'))
+	return err
+}
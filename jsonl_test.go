@@ -0,0 +1,44 @@
+package envoy
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLinesExporter(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	e := JSONLinesExporter{Out: &out, Location: loc}
+	at := time.Date(2024, 6, 1, 19, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	updates := []Update{
+		{Source: "readings", Time: at, Value: Readings{Time: at, Production: 2500, Consumption: 1200, Net: -1300,
+			HasConsumption: true, Battery: -500, SOC: 80, HasBattery: true}},
+		// a site without consumption CTs or batteries
+		{Source: "production", Time: at, Value: Readings{Time: at, Production: 2500.5}},
+		{Source: "inverters", Time: at, Value: []Inverter{
+			{SerialNumber: "122012345678", LastReportDate: Timestamp{at.Add(-time.Minute)}, LastReportWatts: 240},
+		}},
+	}
+	for _, u := range updates {
+		if err := e.Write(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := strings.Join([]string{
+		`{"time":"2024-06-01T12:00:00-07:00","source":"readings","serial":"","production_w":2500,"consumption_w":1200,"net_w":-1300,"battery_w":-500,"soc":80}`,
+		`{"time":"2024-06-01T12:00:00-07:00","source":"production","serial":"","production_w":2500.5,"consumption_w":null,"net_w":null,"battery_w":null,"soc":null}`,
+		`{"time":"2024-06-01T11:59:00-07:00","source":"inverters","serial":"122012345678","production_w":240,"consumption_w":null,"net_w":null,"battery_w":null,"soc":null}`,
+	}, "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
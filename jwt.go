@@ -0,0 +1,128 @@
+package envoy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrMalformedToken is returned if a token is not a well-formed JWT
+var ErrMalformedToken = errors.New("malformed token")
+
+// jwtClaims holds the claims of an Envoy JWT that the package cares about
+type jwtClaims struct {
+	Aud         audience `json:"aud,omitempty"`
+	Iss         string   `json:"iss,omitempty"`
+	EnphaseUser string   `json:"enphaseUser,omitempty"`
+	Username    string   `json:"username,omitempty"`
+	Exp         int64    `json:"exp,omitempty"`
+	Iat         int64    `json:"iat,omitempty"`
+}
+
+// audience decodes the aud claim, which may be a single string or a list of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Token roles reported in the enphaseUser claim
+const (
+	RoleOwner     = "owner"
+	RoleInstaller = "installer"
+)
+
+// TokenInfo describes the claims of an Envoy JWT
+type TokenInfo struct {
+	// Serial is the serial number of the Envoy the token was issued for
+	Serial string
+	// Role is the enphaseUser claim, either RoleOwner or RoleInstaller
+	Role string
+	// Username is the Enlighten account the token was issued to
+	Username  string
+	Issuer    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the token has expired
+func (i TokenInfo) Expired() bool {
+	return !i.ExpiresAt.IsZero() && time.Now().After(i.ExpiresAt)
+}
+
+// ExpiresIn returns the time remaining until the token expires, or zero if it has no expiry
+func (i TokenInfo) ExpiresIn() time.Duration {
+	if i.ExpiresAt.IsZero() {
+		return 0
+	}
+	return time.Until(i.ExpiresAt)
+}
+
+// ParseTokenInfo decodes the claims of *token*. The signature is not verified.
+func ParseTokenInfo(token string) (TokenInfo, error) {
+	claims, err := parseClaims(token)
+	if err != nil {
+		return TokenInfo{}, err
+	}
+	info := TokenInfo{
+		Role:     claims.EnphaseUser,
+		Username: claims.Username,
+		Issuer:   claims.Iss,
+	}
+	if len(claims.Aud) > 0 {
+		info.Serial = claims.Aud[0]
+	}
+	if claims.Iat != 0 {
+		info.IssuedAt = time.Unix(claims.Iat, 0)
+	}
+	if claims.Exp != 0 {
+		info.ExpiresAt = time.Unix(claims.Exp, 0)
+	}
+	return info, nil
+}
+
+// TokenInfo decodes the claims of the token the Client is currently using
+func (c *Client) TokenInfo() (TokenInfo, error) {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+	return ParseTokenInfo(token)
+}
+
+// parseClaims decodes the payload of *token* without verifying its signature, which only the Envoy can do.
+func parseClaims(token string) (jwtClaims, error) {
+	var claims jwtClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformedToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, ErrMalformedToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrMalformedToken
+	}
+	return claims, nil
+}
+
+// tokenExpiry returns the expiry of *token*, or the zero time if it has none or cannot be parsed.
+func tokenExpiry(token string) time.Time {
+	claims, err := parseClaims(token)
+	if err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,40 @@
+//go:build darwin
+
+package envoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of security(1) when no matching item exists
+const errSecItemNotFound = 44
+
+func keyringGet(ctx context.Context, service, user string) (string, error) {
+	out, err := exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", user, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errSecItemNotFound {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// keyringSet runs security(1) interactively with the command on stdin, so the token never appears on a command line
+// where other users could read it with ps
+func keyringSet(ctx context.Context, service, user, token string) error {
+	cmd := exec.CommandContext(ctx, "security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quote(service), quote(user), quote(token)))
+	return cmd.Run()
+}
+
+// quote single-quotes *s* for the shell-like parser of security -i
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
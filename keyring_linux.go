@@ -0,0 +1,29 @@
+//go:build linux
+
+package envoy
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+func keyringGet(ctx context.Context, service, user string) (string, error) {
+	out, err := exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "user", user).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 {
+		// secret-tool exits 1 without output when there is no matching secret
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keyringSet(ctx context.Context, service, user, token string) error {
+	cmd := exec.CommandContext(ctx, "secret-tool", "store", "--label", service, "service", service, "user", user)
+	cmd.Stdin = strings.NewReader(token)
+	return cmd.Run()
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !linux
+
+package envoy
+
+import "context"
+
+func keyringGet(ctx context.Context, service, user string) (string, error) {
+	return "", ErrKeyringUnsupported
+}
+
+func keyringSet(ctx context.Context, service, user, token string) error {
+	return ErrKeyringUnsupported
+}
@@ -0,0 +1,112 @@
+package envoy
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// PanelPosition is where a panel, identified by the serial number of its microinverter, sits in the array layout
+type PanelPosition struct {
+	SerialNumber string `json:"serial_num"`
+	// Array is the label of the array the panel belongs to
+	Array string `json:"array,omitempty"`
+	// X and Y are the position of the panel in the layout, in the layout's units
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	// Rotation is the rotation of the panel in the layout, in degrees
+	Rotation float64 `json:"rotation,omitempty"`
+	// Azimuth is the compass direction the panel faces and Tilt its angle from horizontal, in degrees
+	Azimuth float64 `json:"azimuth,omitempty"`
+	Tilt    float64 `json:"tilt,omitempty"`
+}
+
+// PanelLayout maps microinverter serial numbers to panel positions
+type PanelLayout map[string]PanelPosition
+
+// ReadPanelLayout reads a layout supplied as a JSON list of PanelPositions
+func ReadPanelLayout(r io.Reader) (PanelLayout, error) {
+	var positions []PanelPosition
+	if err := json.NewDecoder(r).Decode(&positions); err != nil {
+		return nil, err
+	}
+	layout := make(PanelLayout, len(positions))
+	for _, p := range positions {
+		layout[p.SerialNumber] = p
+	}
+	return layout, nil
+}
+
+// enlightenLayout is the array layout as exported by Enlighten
+type enlightenLayout struct {
+	Arrays []struct {
+		Label   string  `json:"label"`
+		X       float64 `json:"x"`
+		Y       float64 `json:"y"`
+		Azimuth float64 `json:"azimuth"`
+		Tilt    float64 `json:"tilt"`
+		Modules []struct {
+			X        float64 `json:"x"`
+			Y        float64 `json:"y"`
+			Rotation float64 `json:"rotation"`
+			Inverter struct {
+				SerialNum string `json:"serial_num"`
+			} `json:"inverter"`
+		} `json:"modules"`
+	} `json:"arrays"`
+}
+
+// ReadEnlightenLayout reads the array layout as exported by Enlighten. Module positions, which Enlighten gives
+// relative to their array, are made absolute.
+func ReadEnlightenLayout(r io.Reader) (PanelLayout, error) {
+	var el enlightenLayout
+	if err := json.NewDecoder(r).Decode(&el); err != nil {
+		return nil, err
+	}
+	layout := make(PanelLayout)
+	for _, a := range el.Arrays {
+		for _, m := range a.Modules {
+			if m.Inverter.SerialNum == "" {
+				continue
+			}
+			layout[m.Inverter.SerialNum] = PanelPosition{
+				SerialNumber: m.Inverter.SerialNum,
+				Array:        a.Label,
+				X:            a.X + m.X,
+				Y:            a.Y + m.Y,
+				Rotation:     m.Rotation,
+				Azimuth:      a.Azimuth,
+				Tilt:         a.Tilt,
+			}
+		}
+	}
+	return layout, nil
+}
+
+// PlacedInverter is an inverter report along with the position of its panel, if the layout has it
+type PlacedInverter struct {
+	Inverter
+	Panel *PanelPosition `json:"panel,omitempty"`
+}
+
+// Place attaches the panel position of each inverter in *inverters*, e.g. to render a panel map
+func (l PanelLayout) Place(inverters []Inverter) []PlacedInverter {
+	placed := make([]PlacedInverter, len(inverters))
+	for i, inv := range inverters {
+		placed[i].Inverter = inv
+		if p, ok := l[inv.SerialNumber]; ok {
+			placed[i].Panel = &p
+		}
+	}
+	return placed
+}
+
+// Arrays groups *inverters* by the array their panel belongs to, e.g. to compare each with its peers using
+// Underperformers. Inverters missing from the layout are grouped under "".
+func (l PanelLayout) Arrays(inverters []Inverter) map[string][]Inverter {
+	arrays := make(map[string][]Inverter)
+	for _, inv := range inverters {
+		array := l[inv.SerialNumber].Array
+		arrays[array] = append(arrays[array], inv)
+	}
+	return arrays
+}
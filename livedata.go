@@ -0,0 +1,137 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// LiveDataConnection describes the state of the livedata stream
+type LiveDataConnection struct {
+	MQTTState string `json:"mqtt_state,omitempty"`
+	ProvState string `json:"prov_state,omitempty"`
+	AuthState string `json:"auth_state,omitempty"`
+	// SCStream is enabled while the Envoy is streaming livedata
+	SCStream string `json:"sc_stream,omitempty"`
+	SCDebug  string `json:"sc_debug,omitempty"`
+}
+
+// LiveDataMeter is the aggregate power of one source, in milliwatts, in total and per phase
+type LiveDataMeter struct {
+	AggPMw     int64 `json:"agg_p_mw,omitempty"`
+	AggSMva    int64 `json:"agg_s_mva,omitempty"`
+	AggPPhAMw  int64 `json:"agg_p_ph_a_mw,omitempty"`
+	AggPPhBMw  int64 `json:"agg_p_ph_b_mw,omitempty"`
+	AggPPhCMw  int64 `json:"agg_p_ph_c_mw,omitempty"`
+	AggSPhAMva int64 `json:"agg_s_ph_a_mva,omitempty"`
+	AggSPhBMva int64 `json:"agg_s_ph_b_mva,omitempty"`
+	AggSPhCMva int64 `json:"agg_s_ph_c_mva,omitempty"`
+}
+
+// Watts returns the aggregate real power in W
+func (m LiveDataMeter) Watts() Watts {
+	return Watts(m.AggPMw) / 1000
+}
+
+// LiveDataMeters is the livedata power summary of the whole system
+type LiveDataMeters struct {
+	// LastUpdate is when the data was last updated
+	LastUpdate Timestamp `json:"last_update,omitempty"`
+	SOC        int       `json:"soc,omitempty"`
+	// MainRelayState is 1 while the system is connected to the grid
+	MainRelayState int   `json:"main_relay_state,omitempty"`
+	GenRelayState  int   `json:"gen_relay_state,omitempty"`
+	BackupBatMode  int   `json:"backup_bat_mode,omitempty"`
+	BackupSOC      int   `json:"backup_soc,omitempty"`
+	IsSplitPhase   int   `json:"is_split_phase,omitempty"`
+	PhaseCount     int   `json:"phase_count,omitempty"`
+	EncAggSOC      int   `json:"enc_agg_soc,omitempty"`
+	EncAggEnergy   int64 `json:"enc_agg_energy,omitempty"`
+	ACBAggSOC      int   `json:"acb_agg_soc,omitempty"`
+	ACBAggEnergy   int64 `json:"acb_agg_energy,omitempty"`
+
+	PV        LiveDataMeter `json:"pv,omitempty"`
+	Storage   LiveDataMeter `json:"storage,omitempty"`
+	Grid      LiveDataMeter `json:"grid,omitempty"`
+	Load      LiveDataMeter `json:"load,omitempty"`
+	Generator LiveDataMeter `json:"generator,omitempty"`
+}
+
+// GridConnected reports whether the main relay connects the system to the grid, i.e. it is not in backup
+func (m LiveDataMeters) GridConnected() bool {
+	return m.MainRelayState == 1
+}
+
+// LiveDataDryContact is the state of a dry contact relay as reported in livedata
+type LiveDataDryContact struct {
+	DryContactID       string `json:"dry_contact_id,omitempty"`
+	DryContactType     string `json:"dry_contact_type,omitempty"`
+	DryContactLoadName string `json:"dry_contact_load_name,omitempty"`
+	DryContactStatus   int    `json:"dry_contact_status,omitempty"`
+}
+
+// LiveData is the high-resolution status from /ivp/livedata/status. It only updates while streaming is enabled.
+type LiveData struct {
+	Connection  LiveDataConnection            `json:"connection,omitempty"`
+	Meters      LiveDataMeters                `json:"meters,omitempty"`
+	DryContacts map[string]LiveDataDryContact `json:"dry_contacts,omitempty"`
+}
+
+// LiveData returns the current power of the PV, grid, load and storage, and the backup and grid state. Firmware
+// without livedata returns a *NotSupportedError for CapabilityLiveData.
+func (c *Client) LiveData(ctx context.Context) (LiveData, error) {
+	var live LiveData
+	if err := c.require(ctx, CapabilityLiveData); err != nil {
+		return live, err
+	}
+	err := c.getFeature(ctx, CapabilityLiveData, "/ivp/livedata/status", &live)
+	return live, err
+}
+
+// DefaultLiveDataKeepAlive is how often KeepLiveDataStreaming re-enables the stream. The Envoy stops streaming a few
+// minutes after the last request to enable it.
+const DefaultLiveDataKeepAlive = time.Minute
+
+// liveDataStream is the request body of /ivp/livedata/stream
+type liveDataStream struct {
+	Enable int `json:"enable"`
+}
+
+// EnableLiveData asks the Envoy to start streaming, so that LiveData returns fresh values.
+func (c *Client) EnableLiveData(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/ivp/livedata/stream", liveDataStream{Enable: 1}, nil)
+}
+
+// DisableLiveData asks the Envoy to stop streaming.
+func (c *Client) DisableLiveData(ctx context.Context) error {
+	return c.call(ctx, http.MethodPost, "/ivp/livedata/stream", liveDataStream{Enable: 0}, nil)
+}
+
+// KeepLiveDataStreaming enables streaming and re-enables it every *interval*, or DefaultLiveDataKeepAlive if
+// *interval* is zero, until *ctx* is done. Failures, e.g. while the Envoy reboots, are logged and retried sooner
+// according to the Client's reconnect policy. It returns the context's error, or the last failure once the reconnect
+// policy gives up.
+func (c *Client) KeepLiveDataStreaming(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultLiveDataKeepAlive
+	}
+	failures := 0
+	for {
+		wait := interval
+		if err := c.EnableLiveData(ctx); err != nil && ctx.Err() == nil {
+			failures++
+			c.logger.DebugContext(ctx, "enabling livedata failed", "error", err, "failures", failures)
+			if c.reconnect.exhausted(failures) {
+				return err
+			}
+			if backoff := c.reconnect.backoff(failures); backoff < wait {
+				wait = backoff
+			}
+		} else {
+			failures = 0
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
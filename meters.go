@@ -0,0 +1,134 @@
+package envoy
+
+import "context"
+
+// Measurement types a CT meter may be configured for
+const (
+	MeasurementProduction       = "production"
+	MeasurementNetConsumption   = "net-consumption"
+	MeasurementTotalConsumption = "total-consumption"
+	MeasurementStorage          = "storage"
+)
+
+// Meter is the configuration of a CT meter from /ivp/meters
+type Meter struct {
+	// EID identifies the meter in the readings and reports endpoints
+	EID int64 `json:"eid,omitempty"`
+	// State is enabled or disabled
+	State string `json:"state,omitempty"`
+	// MeasurementType is one of the Measurement constants
+	MeasurementType string `json:"measurementType,omitempty"`
+	// PhaseMode is split, three or single
+	PhaseMode      string   `json:"phaseMode,omitempty"`
+	PhaseCount     int      `json:"phaseCount,omitempty"`
+	MeteringStatus string   `json:"meteringStatus,omitempty"`
+	StatusFlags    []string `json:"statusFlags,omitempty"`
+}
+
+// Enabled reports whether the meter is enabled, and therefore whether its channels in production.json are real
+func (m Meter) Enabled() bool {
+	return m.State == "enabled"
+}
+
+// Meters returns the configuration of the CT meters. Systems without CTs return a *NotSupportedError for
+// CapabilityMeters.
+func (c *Client) Meters(ctx context.Context) ([]Meter, error) {
+	var meters []Meter
+	err := c.getFeature(ctx, CapabilityMeters, "/ivp/meters", &meters)
+	return meters, err
+}
+
+// MeterChannel is an instantaneous reading of a meter, or of one of its phases
+type MeterChannel struct {
+	EID int64 `json:"eid,omitempty"`
+	// Timestamp is when the reading was taken
+	Timestamp Timestamp `json:"timestamp,omitempty"`
+	// ActEnergyDlvd and ActEnergyRcvd are the cumulative active energy delivered and received, in Wh
+	ActEnergyDlvd WattHours `json:"actEnergyDlvd,omitempty"`
+	ActEnergyRcvd WattHours `json:"actEnergyRcvd,omitempty"`
+	// ApparentEnergy is the cumulative apparent energy, in VAh
+	ApparentEnergy float64 `json:"apparentEnergy,omitempty"`
+	// ReactEnergyLagg and ReactEnergyLead are the cumulative reactive energy, in varh
+	ReactEnergyLagg float64 `json:"reactEnergyLagg,omitempty"`
+	ReactEnergyLead float64 `json:"reactEnergyLead,omitempty"`
+	// InstantaneousDemand and ActivePower are in W
+	InstantaneousDemand Watts `json:"instantaneousDemand,omitempty"`
+	ActivePower         Watts `json:"activePower,omitempty"`
+	// ApparentPower is in VA
+	ApparentPower float64 `json:"apparentPower,omitempty"`
+	// ReactivePower is in var
+	ReactivePower float64 `json:"reactivePower,omitempty"`
+	PwrFactor     float64 `json:"pwrFactor,omitempty"`
+	// Voltage is in V, Current in A and Freq in Hz
+	Voltage Volts   `json:"voltage,omitempty"`
+	Current Amps    `json:"current,omitempty"`
+	Freq    float64 `json:"freq,omitempty"`
+}
+
+// MeterReading is the instantaneous reading of a meter from /ivp/meters/readings, totalled over its phases, with
+// the per-phase readings in Channels
+type MeterReading struct {
+	MeterChannel
+	Channels []MeterChannel `json:"channels,omitempty"`
+}
+
+// MeterReadings returns the instantaneous readings of every CT meter, per meter and per phase. Systems without CTs
+// return a *NotSupportedError for CapabilityMeters.
+func (c *Client) MeterReadings(ctx context.Context) ([]MeterReading, error) {
+	var readings []MeterReading
+	err := c.getFeature(ctx, CapabilityMeters, "/ivp/meters/readings", &readings)
+	return readings, err
+}
+
+// MeterReportValues are the readings and cumulative counters of a meter report, for the whole report or one phase
+type MeterReportValues struct {
+	// CurrW, ActPower are in W, ApprntPwr in VA and ReactPwr in var
+	CurrW     Watts   `json:"currW,omitempty"`
+	ActPower  Watts   `json:"actPower,omitempty"`
+	ApprntPwr float64 `json:"apprntPwr,omitempty"`
+	ReactPwr  float64 `json:"reactPwr,omitempty"`
+	// WhDlvdCum and WhRcvdCum are the cumulative energy delivered and received, in Wh
+	WhDlvdCum   WattHours `json:"whDlvdCum,omitempty"`
+	WhRcvdCum   WattHours `json:"whRcvdCum,omitempty"`
+	VarhLagCum  float64   `json:"varhLagCum,omitempty"`
+	VarhLeadCum float64   `json:"varhLeadCum,omitempty"`
+	VahCum      float64   `json:"vahCum,omitempty"`
+	RmsVoltage  Volts     `json:"rmsVoltage,omitempty"`
+	RmsCurrent  Amps      `json:"rmsCurrent,omitempty"`
+	PwrFactor   float64   `json:"pwrFactor,omitempty"`
+	FreqHz      float64   `json:"freqHz,omitempty"`
+}
+
+// MeterReport is a report from /ivp/meters/reports, with cumulative counters for the whole report and per phase in
+// Lines
+type MeterReport struct {
+	// CreatedAt is when the report was generated
+	CreatedAt  Timestamp           `json:"createdAt,omitempty"`
+	ReportType string              `json:"reportType,omitempty"`
+	Cumulative MeterReportValues   `json:"cumulative,omitempty"`
+	Lines      []MeterReportValues `json:"lines,omitempty"`
+}
+
+func (c *Client) meterReport(ctx context.Context, report string) (MeterReport, error) {
+	var r MeterReport
+	err := c.getFeature(ctx, CapabilityMeters, "/ivp/meters/reports/"+report, &r)
+	return r, err
+}
+
+// MeterReportProduction returns the production report. Systems without CTs return a *NotSupportedError for
+// CapabilityMeters.
+func (c *Client) MeterReportProduction(ctx context.Context) (MeterReport, error) {
+	return c.meterReport(ctx, "production")
+}
+
+// MeterReportConsumption returns the total consumption report. Systems without CTs return a *NotSupportedError for
+// CapabilityMeters.
+func (c *Client) MeterReportConsumption(ctx context.Context) (MeterReport, error) {
+	return c.meterReport(ctx, "consumption")
+}
+
+// MeterReportNet returns the net consumption report, i.e. grid import minus export. Systems without CTs return a
+// *NotSupportedError for CapabilityMeters.
+func (c *Client) MeterReportNet(ctx context.Context) (MeterReport, error) {
+	return c.meterReport(ctx, "net-consumption")
+}
@@ -0,0 +1,65 @@
+package envoy
+
+import "net/http"
+
+// Middleware wraps the http.RoundTripper the Client sends requests through, e.g. to add tracing, metrics or
+// recording. Every attempt of a request, including retries and logins, passes through it.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middleware around the Client's transport. The first middleware given is the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithRequestHook calls *hook* with every request before it is sent. The hook receives a copy of the request, so it
+// may modify its headers.
+func WithRequestHook(hook func(*http.Request)) Option {
+	return WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			hook(req)
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+// WithResponseHook calls *hook* with every request once its response, or the error that prevented one, is known.
+// The hook must not read the response body.
+func WithResponseHook(hook func(*http.Request, *http.Response, error)) Option {
+	return WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			hook(req, resp, err)
+			return resp, err
+		})
+	})
+}
+
+// wrapTransport layers debug dumps, digest authentication and middleware over *base*
+func (c *Client) wrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c.debug != nil {
+		// innermost, so the dump shows exactly what goes over the wire
+		base = c.debug.transport(base)
+	}
+	if c.digest != nil {
+		c.digest.next = base
+		base = c.digest
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		base = c.middleware[i](base)
+	}
+	return base
+}
@@ -0,0 +1,109 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Network interface types
+const (
+	InterfaceEthernet = "ethernet"
+	InterfaceWiFi     = "wifi"
+	InterfaceCellular = "cellular"
+)
+
+// NetworkInterface is the configuration and state of one network interface of the Envoy
+type NetworkInterface struct {
+	// Type is InterfaceEthernet, InterfaceWiFi or InterfaceCellular
+	Type      string   `json:"type,omitempty"`
+	Interface string   `json:"interface,omitempty"`
+	MAC       string   `json:"mac,omitempty"`
+	DHCP      bool     `json:"dhcp,omitempty"`
+	IP        string   `json:"ip,omitempty"`
+	Netmask   string   `json:"netmask,omitempty"`
+	Gateway   string   `json:"gateway,omitempty"`
+	DNS       []string `json:"dns,omitempty"`
+	Carrier   bool     `json:"carrier,omitempty"`
+	// SignalStrength is from 0 to SignalStrengthMax, for wireless interfaces
+	SignalStrength    int `json:"signal_strength,omitempty"`
+	SignalStrengthMax int `json:"signal_strength_max,omitempty"`
+}
+
+// NetworkSettings is the network configuration from /admin/lib/network_display.json
+type NetworkSettings struct {
+	PrimaryInterface string             `json:"primary_interface,omitempty"`
+	Interfaces       []NetworkInterface `json:"interfaces,omitempty"`
+	// LastEnlightenReportTime is when the Envoy last reported to Enlighten
+	LastEnlightenReportTime Timestamp `json:"last_enlighten_report_time,omitempty"`
+}
+
+// Primary returns the interface the Envoy uses to reach Enlighten, and false if it is not listed
+func (n NetworkSettings) Primary() (NetworkInterface, bool) {
+	for _, iface := range n.Interfaces {
+		if iface.Interface == n.PrimaryInterface {
+			return iface, true
+		}
+	}
+	return NetworkInterface{}, false
+}
+
+// NetworkSettings returns the network configuration of the Envoy: interface types, addresses, DNS servers and
+// gateways, and when it last reported to Enlighten.
+func (c *Client) NetworkSettings(ctx context.Context) (NetworkSettings, error) {
+	var settings NetworkSettings
+	err := c.get(ctx, "/admin/lib/network_display.json", &settings)
+	return settings, err
+}
+
+// wirelessPath serves the Wi-Fi configuration
+const wirelessPath = "/admin/lib/wireless_display.json"
+
+// WiFiNetwork is a wireless network, either the one the Envoy is joined to or one it can see
+type WiFiNetwork struct {
+	SSID           string `json:"ssid,omitempty"`
+	Status         string `json:"status,omitempty"`
+	SecurityMode   string `json:"security_mode,omitempty"`
+	IPAddress      string `json:"ip_address,omitempty"`
+	SignalStrength int    `json:"signal_strength,omitempty"`
+}
+
+// WiFiSettings is the Wi-Fi configuration from /admin/lib/wireless_display.json
+type WiFiSettings struct {
+	Supported         bool          `json:"supported,omitempty"`
+	Present           bool          `json:"present,omitempty"`
+	Configured        bool          `json:"configured,omitempty"`
+	Up                bool          `json:"up,omitempty"`
+	Carrier           bool          `json:"carrier,omitempty"`
+	CurrentNetwork    WiFiNetwork   `json:"current_network,omitempty"`
+	SelectedRegion    string        `json:"selected_region,omitempty"`
+	AvailableNetworks []WiFiNetwork `json:"available_networks,omitempty"`
+}
+
+// WiFiSettings returns the current Wi-Fi configuration and the networks in range.
+func (c *Client) WiFiSettings(ctx context.Context) (WiFiSettings, error) {
+	var settings WiFiSettings
+	err := c.get(ctx, wirelessPath, &settings)
+	return settings, err
+}
+
+// JoinWiFi makes the Envoy join the network *ssid* with passphrase *key* and *securityMode* such as wpa2-psk. If the
+// new network does not work, the Envoy may become unreachable until reconfigured over its access point, so the
+// action is subject to the Client's ConfirmFunc.
+func (c *Client) JoinWiFi(ctx context.Context, ssid, key, securityMode string) error {
+	if ssid == "" {
+		return fmt.Errorf("empty ssid")
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("join wifi network %s", ssid)); err != nil {
+		return err
+	}
+	body := struct {
+		Network struct {
+			SSID         string `json:"ssid"`
+			Key          string `json:"key"`
+			SecurityMode string `json:"security_mode"`
+		} `json:"network"`
+	}{}
+	body.Network.SSID, body.Network.Key, body.Network.SecurityMode = ssid, key, securityMode
+	return c.call(ctx, http.MethodPut, wirelessPath, body, nil)
+}
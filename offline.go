@@ -0,0 +1,147 @@
+package envoy
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OfflineEvent reports an inverter that missed its reports during daylight, or one that is reporting again
+type OfflineEvent struct {
+	SerialNumber string
+	Time         time.Time
+	// LastReport is when the inverter last reported
+	LastReport time.Time
+	// Offline is set when the inverter went offline and cleared when it came back
+	Offline bool
+}
+
+// OfflineMonitor tracks the last report of each inverter and reports those that stop reporting while the sun is up.
+// Microinverters are unpowered at night, so silence then is not a fault. The zero value is ready to use.
+type OfflineMonitor struct {
+	// StaleAfter is how long an inverter may go without reporting before it is offline, DefaultStaleAfter if zero
+	StaleAfter time.Duration
+	// Daylight reports whether inverters should be producing at a given time, e.g. SunDaylight for the site. If
+	// nil, it is daylight whenever any inverter has recently reported power.
+	Daylight func(time.Time) bool
+
+	mu      sync.Mutex
+	offline map[string]bool
+}
+
+// Watch observes the []Inverter values of *updates*, e.g. a Poller subscription, and delivers OfflineEvents on
+// the returned channel, which is closed when *updates* is closed or *ctx* is done
+func (m *OfflineMonitor) Watch(ctx context.Context, updates <-chan Update) <-chan OfflineEvent {
+	events := make(chan OfflineEvent)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u, ok := <-updates:
+				if !ok {
+					return
+				}
+				inverters, ok := u.Value.([]Inverter)
+				if !ok || u.Err != nil {
+					continue
+				}
+				for _, e := range m.Observe(u.Time, inverters) {
+					select {
+					case events <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return events
+}
+
+// Observe checks the inverter reports polled at *now* and returns the inverters that went offline or came back,
+// sorted by serial number. Outside daylight nothing changes.
+func (m *OfflineMonitor) Observe(now time.Time, inverters []Inverter) []OfflineEvent {
+	stale := m.StaleAfter
+	if stale <= 0 {
+		stale = DefaultStaleAfter
+	}
+	daylight := m.Daylight
+	if daylight == nil {
+		daylight = func(time.Time) bool { return anyProducing(now, inverters, stale) }
+	}
+	if !daylight(now) {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.offline == nil {
+		m.offline = make(map[string]bool)
+	}
+	var events []OfflineEvent
+	for _, i := range inverters {
+		last := i.LastReportDate.Time
+		offline := now.Sub(last) > stale
+		if offline != m.offline[i.SerialNumber] {
+			events = append(events, OfflineEvent{SerialNumber: i.SerialNumber, Time: now, LastReport: last, Offline: offline})
+		}
+		m.offline[i.SerialNumber] = offline
+	}
+	sort.Slice(events, func(a, b int) bool { return events[a].SerialNumber < events[b].SerialNumber })
+	return events
+}
+
+// Offline returns the serial numbers of the inverters currently considered offline, sorted
+func (m *OfflineMonitor) Offline() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var serials []string
+	for serial, offline := range m.offline {
+		if offline {
+			serials = append(serials, serial)
+		}
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// anyProducing reports whether any inverter reported power within *stale* of *now*
+func anyProducing(now time.Time, inverters []Inverter, stale time.Duration) bool {
+	for _, i := range inverters {
+		if i.LastReportWatts > 0 && now.Sub(i.LastReportDate.Time) <= stale {
+			return true
+		}
+	}
+	return false
+}
+
+// minDaylightElevation is how high the sun must be, in degrees, before inverters are expected to produce
+const minDaylightElevation = 5
+
+// SunDaylight returns a Daylight function for an OfflineMonitor that is true while the sun is at least a few
+// degrees above the horizon at *latitude* and *longitude*, in degrees
+func SunDaylight(latitude, longitude float64) func(time.Time) bool {
+	return func(t time.Time) bool {
+		return solarElevation(t, latitude, longitude) >= minDaylightElevation
+	}
+}
+
+// solarElevation approximates the elevation of the sun in degrees, using the NOAA general solar position equations
+func solarElevation(t time.Time, latitude, longitude float64) float64 {
+	t = t.UTC()
+	hours := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	g := 2 * math.Pi / 365 * (float64(t.YearDay()-1) + (hours-12)/24)
+	eqtime := 229.18 * (0.000075 + 0.001868*math.Cos(g) - 0.032077*math.Sin(g) - 0.014615*math.Cos(2*g) -
+		0.040849*math.Sin(2*g))
+	decl := 0.006918 - 0.399912*math.Cos(g) + 0.070257*math.Sin(g) - 0.006758*math.Cos(2*g) +
+		0.000907*math.Sin(2*g) - 0.002697*math.Cos(3*g) + 0.00148*math.Sin(3*g)
+	trueSolarMinutes := hours*60 + eqtime + 4*longitude
+	hourAngle := (trueSolarMinutes/4 - 180) * math.Pi / 180
+	lat := latitude * math.Pi / 180
+	cosZenith := math.Sin(lat)*math.Sin(decl) + math.Cos(lat)*math.Cos(decl)*math.Cos(hourAngle)
+	return 90 - math.Acos(math.Max(-1, math.Min(1, cosZenith)))*180/math.Pi
+}
@@ -0,0 +1,110 @@
+package envoy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithHTTPClient makes the Client use the provided http.Client instead of creating its own.
+// WithTLSConfig and certificate pinning have no effect on a client supplied this way.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithProto sets the protocol used to reach the Envoy, either "http" or "https". The default is "https".
+func WithProto(proto string) Option {
+	return func(c *Client) {
+		c.proto = proto
+	}
+}
+
+// WithToken sets the JWT used to authenticate against the Envoy.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// DefaultTimeout bounds each call made by a Client unless WithTimeout says otherwise
+const DefaultTimeout = 30 * time.Second
+
+// WithTimeout sets the overall timeout of each call made by the Client, including any login it requires. A zero or
+// negative timeout disables it. A deadline already set on the context passed to a call takes precedence.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for https connections. By default certificate verification is disabled,
+// since the Envoy uses a self-signed certificate; a supplied configuration is used as-is, so it verifies certificates
+// unless it sets InsecureSkipVerify itself. The configuration is copied, so it may be reused by the caller.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = config.Clone()
+	}
+}
+
+// WithRootCAs verifies the server certificate against *roots*, e.g. when the Envoy sits behind a reverse proxy with a
+// certificate from a private CA. It enables certificate verification.
+func WithRootCAs(roots *x509.CertPool) Option {
+	return func(c *Client) {
+		c.ensureTLSConfig().RootCAs = roots
+	}
+}
+
+// WithClientCertificate presents *cert* to servers that request a client certificate. It enables certificate
+// verification.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(c *Client) {
+		config := c.ensureTLSConfig()
+		config.Certificates = append(config.Certificates, cert)
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version accepted, e.g. tls.VersionTLS12. It enables certificate verification.
+func WithMinTLSVersion(version uint16) Option {
+	return func(c *Client) {
+		c.ensureTLSConfig().MinVersion = version
+	}
+}
+
+// ensureTLSConfig returns the custom TLS configuration, creating a verifying one if none has been set
+func (c *Client) ensureTLSConfig() *tls.Config {
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{}
+	}
+	return c.tlsConfig
+}
+
+// WithLogger sets the logger the Client writes debug messages about requests, retries, logins and decoding failures
+// to. By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// WithHeader adds a header sent with every request, e.g. for a reverse proxy that routes on headers. Headers set on a
+// request passed to Do take precedence.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header)
+		}
+		c.headers.Add(key, value)
+	}
+}
@@ -0,0 +1,38 @@
+package envoy
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// pcuCommCheckTimeout bounds PCUCommCheck unless the caller sets a deadline, since the check takes far longer than
+// the Client's usual timeout
+const pcuCommCheckTimeout = 5 * time.Minute
+
+// PCUSignal is the powerline communication result of one device
+type PCUSignal struct {
+	SerialNumber string
+	// Level is the signal strength, from 0 (no communication) to 5
+	Level int
+}
+
+// PCUCommCheck triggers a powerline communication check of every device and returns the signal level of each,
+// sorted by serial number. The check takes a minute or more. It requires an installer token.
+func (c *Client) PCUCommCheck(ctx context.Context) ([]PCUSignal, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pcuCommCheckTimeout)
+		defer cancel()
+	}
+	var levels map[string]int
+	if err := c.get(ctx, "/installer/pcu_comm_check", &levels); err != nil {
+		return nil, err
+	}
+	signals := make([]PCUSignal, 0, len(levels))
+	for serial, level := range levels {
+		signals = append(signals, PCUSignal{SerialNumber: serial, Level: level})
+	}
+	sort.Slice(signals, func(i, j int) bool { return signals[i].SerialNumber < signals[j].SerialNumber })
+	return signals, nil
+}
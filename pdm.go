@@ -0,0 +1,35 @@
+package envoy
+
+import "context"
+
+// PDMCounters are the energy counters of one channel of the production data module
+type PDMCounters struct {
+	WattHoursToday     int64 `json:"wattHoursToday,omitempty"`
+	WattHoursSevenDays int64 `json:"wattHoursSevenDays,omitempty"`
+	WattHoursLifetime  int64 `json:"wattHoursLifetime,omitempty"`
+	WattsNow           int64 `json:"wattsNow,omitempty"`
+}
+
+// PDMChannels are the channels measuring production or consumption. A channel the system lacks is nil.
+type PDMChannels struct {
+	// PCU is computed from the microinverter reports
+	PCU *PDMCounters `json:"pcu,omitempty"`
+	// RGM is the revenue-grade meter, if installed
+	RGM *PDMCounters `json:"rgm,omitempty"`
+	// EIM is the integrated CT meter
+	EIM *PDMCounters `json:"eim,omitempty"`
+}
+
+// PDMEnergy is the response of /ivp/pdm/energy
+type PDMEnergy struct {
+	Production  PDMChannels `json:"production,omitempty"`
+	Consumption PDMChannels `json:"consumption,omitempty"`
+}
+
+// PDMEnergy returns the production data module's today, seven-day and lifetime counters per channel, which are
+// computed differently from production.json and are needed to reconcile the two.
+func (c *Client) PDMEnergy(ctx context.Context) (PDMEnergy, error) {
+	var energy PDMEnergy
+	err := c.get(ctx, "/ivp/pdm/energy", &energy)
+	return energy, err
+}
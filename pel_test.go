@@ -0,0 +1,34 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetPowerExportLimitWaitsForInverters(t *testing.T) {
+	f := newFakeEnvoy(t)
+	var reads atomic.Int32
+	f.handle(pelPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			return
+		}
+		status := "pending"
+		if reads.Add(1) >= 3 {
+			status = "applied"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"pel_settings":{"enable":true,"limit":5000,"mode":"site","status":"` + status + `"}}`))
+	})
+
+	c := f.client(WithPowerExportLimitPollInterval(time.Millisecond))
+	limit := PowerExportLimit{Enabled: true, LimitW: 5000, Mode: "site"}
+	if err := c.SetPowerExportLimit(context.Background(), limit); err != nil {
+		t.Fatal(err)
+	}
+	if n := reads.Load(); n != 3 {
+		t.Errorf("read the limit back %d times, want 3", n)
+	}
+}
@@ -0,0 +1,121 @@
+package envoy
+
+import "math"
+
+// MeterPhase is the reading of one phase of a meter
+type MeterPhase struct {
+	// P is real power in W, Q reactive power in var and S apparent power in VA
+	P Watts   `json:"p"`
+	Q float64 `json:"q"`
+	S float64 `json:"s"`
+	// V is voltage in V and I current in A
+	V Volts `json:"v"`
+	I Amps  `json:"i"`
+	// PF is the power factor and F the frequency in Hz
+	PF float64 `json:"pf"`
+	F  float64 `json:"f"`
+}
+
+// Phases is the reading of a meter by phase, as in the stream's ph-a, ph-b and ph-c. A phase the site lacks is nil:
+// split-phase sites have two and EU three-phase sites three.
+type Phases struct {
+	PhaseA *MeterPhase `json:"ph-a,omitempty"`
+	PhaseB *MeterPhase `json:"ph-b,omitempty"`
+	PhaseC *MeterPhase `json:"ph-c,omitempty"`
+}
+
+// phasesOf assigns *readings* to phases A, B and C in order
+func phasesOf(readings []MeterPhase) Phases {
+	var p Phases
+	for i, slot := range []**MeterPhase{&p.PhaseA, &p.PhaseB, &p.PhaseC} {
+		if i < len(readings) {
+			r := readings[i]
+			*slot = &r
+		}
+	}
+	return p
+}
+
+// All returns the phases present, in order
+func (p Phases) All() []MeterPhase {
+	var all []MeterPhase
+	for _, ph := range []*MeterPhase{p.PhaseA, p.PhaseB, p.PhaseC} {
+		if ph != nil {
+			all = append(all, *ph)
+		}
+	}
+	return all
+}
+
+// Sum returns *value* summed over the phases
+func (p Phases) Sum(value func(MeterPhase) float64) float64 {
+	var sum float64
+	for _, ph := range p.All() {
+		sum += value(ph)
+	}
+	return sum
+}
+
+// Watts returns the real power summed over the phases
+func (p Phases) Watts() Watts {
+	return Watts(p.Sum(func(ph MeterPhase) float64 { return float64(ph.P) }))
+}
+
+// Imbalance returns the largest deviation of *value* from its mean over the phases, as a fraction of the mean. It
+// is 0 with fewer than two phases or a zero mean.
+func (p Phases) Imbalance(value func(MeterPhase) float64) float64 {
+	all := p.All()
+	if len(all) < 2 {
+		return 0
+	}
+	mean := p.Sum(value) / float64(len(all))
+	if mean == 0 {
+		return 0
+	}
+	var dev float64
+	for _, ph := range all {
+		dev = math.Max(dev, math.Abs(value(ph)-mean))
+	}
+	return dev / math.Abs(mean)
+}
+
+// VoltageImbalance returns the voltage imbalance between the phases, e.g. 0.02 for 2%. Sustained voltage imbalance
+// above a few percent stresses motors and inverters.
+func (p Phases) VoltageImbalance() float64 {
+	return p.Imbalance(func(ph MeterPhase) float64 { return float64(ph.V) })
+}
+
+// CurrentImbalance returns the current imbalance between the phases, e.g. 0.25 for 25%
+func (p Phases) CurrentImbalance() float64 {
+	return p.Imbalance(func(ph MeterPhase) float64 { return float64(ph.I) })
+}
+
+// Phases returns the per-phase readings of the meter
+func (r MeterReading) Phases() Phases {
+	readings := make([]MeterPhase, len(r.Channels))
+	for i, ch := range r.Channels {
+		readings[i] = MeterPhase{P: ch.ActivePower, Q: ch.ReactivePower, S: ch.ApparentPower, V: ch.Voltage,
+			I: ch.Current, PF: ch.PwrFactor, F: ch.Freq}
+	}
+	return phasesOf(readings)
+}
+
+// Phases returns the per-phase values of the report
+func (r MeterReport) Phases() Phases {
+	readings := make([]MeterPhase, len(r.Lines))
+	for i, l := range r.Lines {
+		readings[i] = MeterPhase{P: l.ActPower, Q: l.ReactPwr, S: l.ApprntPwr, V: l.RmsVoltage, I: l.RmsCurrent,
+			PF: l.PwrFactor, F: l.FreqHz}
+	}
+	return phasesOf(readings)
+}
+
+// Phases returns the per-phase readings of a meter reading from production.json. Frequency is not reported there.
+func (d ProductionData) Phases() Phases {
+	readings := make([]MeterPhase, len(d.Lines))
+	for i, l := range d.Lines {
+		readings[i] = MeterPhase{P: l.WNow, Q: l.ReactPwr, S: l.ApprntPwr, V: l.RmsVoltage, I: l.RmsCurrent,
+			PF: l.PwrFactor}
+	}
+	return phasesOf(readings)
+}
@@ -0,0 +1,82 @@
+package envoy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrCertificateMismatch is returned if the Envoy presents a certificate other than the pinned one
+var ErrCertificateMismatch = errors.New("certificate does not match pinned fingerprint")
+
+// WithPinnedCertificate makes the Client accept only an Envoy certificate whose SHA-256 fingerprint is *fingerprint*,
+// given in hex with or without colons. This replaces disabling certificate verification entirely.
+func WithPinnedCertificate(fingerprint string) Option {
+	return func(c *Client) {
+		fp, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+		if err != nil || len(fp) != sha256.Size {
+			// an unusable fingerprint must not silently fall back to accepting any certificate
+			fp = []byte{}
+		}
+		c.pin = &certPin{fingerprint: fp}
+	}
+}
+
+// WithTrustOnFirstUse makes the Client pin whichever certificate the Envoy presents on the first connection, and
+// reject any different certificate after that. Use CertificateFingerprint to persist the learned fingerprint.
+func WithTrustOnFirstUse() Option {
+	return func(c *Client) {
+		c.pin = &certPin{}
+	}
+}
+
+// CertificateFingerprint returns the hex SHA-256 fingerprint the Client has pinned, or "" if nothing is pinned yet
+func (c *Client) CertificateFingerprint() string {
+	if c.pin == nil {
+		return ""
+	}
+	c.pin.mu.Lock()
+	defer c.pin.mu.Unlock()
+	return hex.EncodeToString(c.pin.fingerprint)
+}
+
+// certPin holds the fingerprint of the certificate the Client trusts. A nil fingerprint is learned on first use.
+type certPin struct {
+	mu          sync.Mutex
+	fingerprint []byte
+}
+
+func (p *certPin) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrCertificateMismatch
+	}
+	sum := sha256.Sum256(cs.PeerCertificates[0].Raw)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fingerprint == nil {
+		p.fingerprint = sum[:]
+		return nil
+	}
+	if !bytes.Equal(p.fingerprint, sum[:]) {
+		return ErrCertificateMismatch
+	}
+	return nil
+}
+
+// tlsConfig returns a copy of *base* that verifies the peer against the pin instead of the system roots
+func (p *certPin) tlsConfig(base *tls.Config) *tls.Config {
+	var config *tls.Config
+	if base != nil {
+		config = base.Clone()
+	} else {
+		config = &tls.Config{}
+	}
+	// the Envoy's certificate is self-signed, so chain verification is replaced by the fingerprint check
+	config.InsecureSkipVerify = true
+	config.VerifyConnection = p.verify
+	return config
+}
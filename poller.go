@@ -0,0 +1,159 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrPollerStarted is returned when a Poller is started a second time
+var ErrPollerStarted = errors.New("poller already started")
+
+// DefaultPollInterval is how often a Poller polls unless NewPoller is given an interval
+const DefaultPollInterval = 30 * time.Second
+
+// Source is one endpoint a Poller polls
+type Source struct {
+	// Name identifies the source in its Updates
+	Name string
+	// Fetch polls the source
+	Fetch func(ctx context.Context, c *Client) (interface{}, error)
+	// Interval is how often the source is polled, or the Poller's interval if zero
+	Interval time.Duration
+	// Jitter randomizes each interval by up to this fraction in either direction, between 0 and 1, so sources with
+	// the same cadence do not all hit the Envoy at once
+	Jitter float64
+}
+
+// Every returns a copy of *s* polled every *interval* with *jitter*, e.g. LiveData every 2 seconds but Inventory
+// every 10 minutes
+func (s Source) Every(interval time.Duration, jitter float64) Source {
+	s.Interval, s.Jitter = interval, jitter
+	return s
+}
+
+// NewSource returns a Source that polls *fetch*, typically a method expression such as (*Client).Production
+func NewSource[T any](name string, fetch func(*Client, context.Context) (T, error)) Source {
+	return Source{
+		Name: name,
+		Fetch: func(ctx context.Context, c *Client) (interface{}, error) {
+			return fetch(c, ctx)
+		},
+	}
+}
+
+// Update is the result of one poll of a Source
+type Update struct {
+	Source string
+	Time   time.Time
+	// Value is what the Source returned, e.g. a Production, or nil if Err is set
+	Value interface{}
+	Err   error
+}
+
+// Poller polls a set of Sources and delivers the results to its subscribers
+type Poller struct {
+	client   *Client
+	interval time.Duration
+	sources  []Source
+
+	mu      sync.Mutex
+	subs    []chan Update
+	started bool
+	done    chan struct{}
+}
+
+// NewPoller creates a Poller that polls *sources* through *client* every *interval*, or DefaultPollInterval if
+// *interval* is zero. Sources with an Interval of their own keep their cadence.
+func NewPoller(client *Client, interval time.Duration, sources ...Source) *Poller {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Poller{client: client, interval: interval, sources: sources, done: make(chan struct{})}
+}
+
+// Subscribe returns a channel receiving every Update, buffered to hold *buffer* of them. Updates are dropped for a
+// subscriber whose buffer is full, so a slow consumer never holds up the polls. The channel is closed once the
+// Poller has stopped.
+func (p *Poller) Subscribe(buffer int) <-chan Update {
+	ch := make(chan Update, buffer)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.done:
+		close(ch)
+	default:
+		p.subs = append(p.subs, ch)
+	}
+	return ch
+}
+
+// Start polls each Source immediately and then at its interval, in the background, until *ctx* is done
+func (p *Poller) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return ErrPollerStarted
+	}
+	p.started = true
+
+	var wg sync.WaitGroup
+	for _, source := range p.sources {
+		wg.Add(1)
+		go func(source Source) {
+			defer wg.Done()
+			p.run(ctx, source)
+		}(source)
+	}
+	go func() {
+		wg.Wait()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		close(p.done)
+		for _, ch := range p.subs {
+			close(ch)
+		}
+		p.subs = nil
+	}()
+	return nil
+}
+
+// Done returns a channel that is closed once the Poller has stopped
+func (p *Poller) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *Poller) run(ctx context.Context, source Source) {
+	interval := source.Interval
+	if interval <= 0 {
+		interval = p.interval
+	}
+	// the wait before a first retry is the interval, jittered
+	policy := RetryPolicy{InitialBackoff: interval, Jitter: source.Jitter}
+	for {
+		value, err := source.Fetch(ctx, p.client)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			value = nil
+			p.client.logger.DebugContext(ctx, "poll failed", "source", source.Name, "error", err)
+		}
+		p.publish(Update{Source: source.Name, Time: time.Now(), Value: value, Err: err})
+		if sleep(ctx, policy.backoff(1, nil)) != nil {
+			return
+		}
+	}
+}
+
+func (p *Poller) publish(u Update) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, ch := range p.subs {
+		select {
+		case ch <- u:
+		default:
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+)
+
+// powerPath controls production power; 603980032 is the EID of the Envoy itself
+const powerPath = "/ivp/mod/603980032/mode/power"
+
+type powerMode struct {
+	Length int   `json:"length"`
+	Arr    []int `json:"arr"`
+}
+
+// ProductionPowerForcedOff reports whether production has been switched off with PowerProductionOff.
+func (c *Client) ProductionPowerForcedOff(ctx context.Context) (bool, error) {
+	var status struct {
+		PowerForcedOff bool `json:"powerForcedOff,omitempty"`
+	}
+	err := c.get(ctx, powerPath, &status)
+	return status.PowerForcedOff, err
+}
+
+// PowerProductionOn lets the inverters resume producing after PowerProductionOff. The action is subject to the
+// Client's ConfirmFunc and dry-run mode.
+func (c *Client) PowerProductionOn(ctx context.Context) error {
+	if err := c.confirm(ctx, "power production on"); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, powerPath, powerMode{Length: 1, Arr: []int{0}}, nil)
+}
+
+// PowerProductionOff stops all inverters from producing, e.g. for rapid-shutdown tests or an emergency stop. The
+// action is subject to the Client's ConfirmFunc and dry-run mode.
+func (c *Client) PowerProductionOff(ctx context.Context) error {
+	if err := c.confirm(ctx, "power production off"); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, powerPath, powerMode{Length: 1, Arr: []int{1}}, nil)
+}
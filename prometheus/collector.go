@@ -0,0 +1,167 @@
+// Package prometheus exposes an Envoy's readings as Prometheus metrics, so the Client can be wired into an existing
+// exporter binary. Imported as envoyprom alongside client_golang:
+//
+//	registry := prometheus.NewRegistry()
+//	registry.MustRegister(envoyprom.NewCollector(client, envoyprom.WithConstLabels(prometheus.Labels{"site": "home"})))
+//	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+//
+// The Envoy is polled when Prometheus scrapes, so the scrape interval is also the polling interval. The package is a
+// module of its own, so importing the client alone does not pull in client_golang.
+package prometheus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gcochard/go-envoy"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "envoy"
+
+// Collector is a prometheus.Collector reporting the site's power, lifetime energy, battery and inverters, and the
+// Client's request counters
+type Collector struct {
+	client      *envoy.Client
+	timeout     time.Duration
+	constLabels prometheus.Labels
+
+	up, production, consumption, net, battery, soc    *prometheus.Desc
+	producedTotal, consumedTotal                      *prometheus.Desc
+	inverterWatts, inverterMaxWatts, inverterReported *prometheus.Desc
+	requests, retries, failures, errorResponses       *prometheus.Desc
+	requestSeconds                                    *prometheus.Desc
+
+	// mu serializes scrapes so the energy counters see the readings in order
+	mu       sync.Mutex
+	produced envoy.EnergyCounter
+	consumed envoy.EnergyCounter
+}
+
+// Option configures a Collector
+type Option func(*Collector)
+
+// WithConstLabels adds *labels* to every metric, e.g. the site name when one exporter serves several Envoys
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *Collector) {
+		c.constLabels = labels
+	}
+}
+
+// WithTimeout bounds how long a scrape waits for the Envoy. By default the Client's own timeout applies.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Collector) {
+		c.timeout = d
+	}
+}
+
+// NewCollector creates a Collector reading from *client*
+func NewCollector(client *envoy.Client, opts ...Option) *Collector {
+	c := &Collector{client: client}
+	for _, opt := range opts {
+		opt(c)
+	}
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, c.constLabels)
+	}
+	c.up = desc("up", "Whether the last scrape of the Envoy succeeded.")
+	c.production = desc("production_watts", "Solar production.")
+	c.consumption = desc("consumption_watts", "Power used by the site.")
+	c.net = desc("net_consumption_watts", "Power drawn from the grid, negative while exporting.")
+	c.battery = desc("battery_watts", "Power delivered by the batteries, negative while charging.")
+	c.soc = desc("battery_soc_percent", "Average state of charge of the batteries.")
+	c.producedTotal = desc("production_wh_total", "Energy produced, tolerant of meter resets.")
+	c.consumedTotal = desc("consumption_wh_total", "Energy consumed, tolerant of meter resets.")
+	c.inverterWatts = desc("inverter_watts", "Last power reported by the inverter.", "serial")
+	c.inverterMaxWatts = desc("inverter_max_watts", "Highest power reported by the inverter.", "serial")
+	c.inverterReported = desc("inverter_last_report_timestamp_seconds", "When the inverter last reported.", "serial")
+	c.requests = desc("client_requests_total", "HTTP requests sent to the Envoy, including retries.")
+	c.retries = desc("client_retries_total", "HTTP requests that were retries.")
+	c.failures = desc("client_failures_total", "HTTP requests that got no response.")
+	c.errorResponses = desc("client_error_responses_total", "HTTP requests answered with an error status.")
+	c.requestSeconds = desc("client_request_duration_seconds_total", "Time spent waiting for responses.")
+	return c
+}
+
+// Describe sends the descriptors of the metrics
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.up, c.production, c.consumption, c.net, c.battery, c.soc, c.producedTotal, c.consumedTotal,
+		c.inverterWatts, c.inverterMaxWatts, c.inverterReported, c.requests, c.retries, c.failures,
+		c.errorResponses, c.requestSeconds,
+	} {
+		ch <- d
+	}
+}
+
+// Collect polls the Envoy and sends the metrics. Metrics the Envoy has no readings for are left out.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	r, err := c.client.Readings(ctx)
+	if err != nil {
+		gauge(c.up, 0)
+	} else {
+		gauge(c.up, 1)
+		gauge(c.production, float64(r.Production))
+		if r.ProductionLifetime > 0 {
+			counter(c.producedTotal, float64(c.produced.Observe(r.ProductionLifetime)))
+		}
+		if r.HasConsumption {
+			gauge(c.consumption, float64(r.Consumption))
+			gauge(c.net, float64(r.Net))
+			if r.ConsumptionLifetime > 0 {
+				counter(c.consumedTotal, float64(c.consumed.Observe(r.ConsumptionLifetime)))
+			}
+		}
+		if r.HasBattery {
+			gauge(c.battery, float64(r.Battery))
+			gauge(c.soc, r.SOC)
+		}
+	}
+
+	if inverters, err := c.client.Inverters(ctx); err == nil {
+		for _, i := range inverters {
+			gauge(c.inverterWatts, float64(i.LastReportWatts), i.SerialNumber)
+			gauge(c.inverterMaxWatts, float64(i.MaxReportWatts), i.SerialNumber)
+			if !i.LastReportDate.IsZero() {
+				gauge(c.inverterReported, float64(i.LastReportDate.Unix()), i.SerialNumber)
+			}
+		}
+	}
+
+	s := c.client.Stats()
+	counter(c.requests, float64(s.Requests))
+	counter(c.retries, float64(s.Retries))
+	counter(c.failures, float64(s.Failures))
+	counter(c.errorResponses, float64(s.ErrorResponses))
+	counter(c.requestSeconds, s.Duration.Seconds())
+}
+
+// State returns the state of the lifetime energy counters, to save across restarts so the totals never go backwards
+func (c *Collector) State() (production, consumption envoy.EnergyCounterState) {
+	return c.produced.State(), c.consumed.State()
+}
+
+// Restore replaces the state of the lifetime energy counters with one saved by State
+func (c *Collector) Restore(production, consumption envoy.EnergyCounterState) {
+	c.produced.Restore(production)
+	c.consumed.Restore(consumption)
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
@@ -0,0 +1,99 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gcochard/go-envoy"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testProduction = `{
+	"production": [
+		{"type": "inverters", "activeCount": 2, "wNow": 480},
+		{"type": "eim", "activeCount": 1, "measurementType": "production", "wNow": 500, "whLifetime": 1200000}
+	],
+	"consumption": [
+		{"type": "eim", "measurementType": "total-consumption", "wNow": 800, "whLifetime": 900000},
+		{"type": "eim", "measurementType": "net-consumption", "wNow": 300}
+	]
+}`
+
+const testInverters = `[
+	{"serialNumber": "122012345678", "lastReportDate": 1717243200, "lastReportWatts": 240, "maxReportWatts": 295},
+	{"serialNumber": "122012345679", "lastReportDate": 1717243210, "lastReportWatts": 245, "maxReportWatts": 296}
+]`
+
+// newEnvoy serves the readings of a firmware 5.x Envoy, which needs no login
+func newEnvoy(t *testing.T) *envoy.Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<envoy_info><device><sn>122012345678</sn><software>D5.0.49</software></device></envoy_info>`))
+	})
+	mux.HandleFunc("/production.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testProduction))
+	})
+	mux.HandleFunc("/api/v1/production/inverters", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testInverters))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return envoy.NewClient(strings.TrimPrefix(server.URL, "http://"), envoy.WithProto("http"),
+		envoy.WithRetryPolicy(envoy.NoRetry))
+}
+
+func TestCollector(t *testing.T) {
+	c := NewCollector(newEnvoy(t), WithConstLabels(map[string]string{"site": "home"}))
+	want := `
+# HELP envoy_up Whether the last scrape of the Envoy succeeded.
+# TYPE envoy_up gauge
+envoy_up{site="home"} 1
+# HELP envoy_production_watts Solar production.
+# TYPE envoy_production_watts gauge
+envoy_production_watts{site="home"} 500
+# HELP envoy_consumption_watts Power used by the site.
+# TYPE envoy_consumption_watts gauge
+envoy_consumption_watts{site="home"} 800
+# HELP envoy_net_consumption_watts Power drawn from the grid, negative while exporting.
+# TYPE envoy_net_consumption_watts gauge
+envoy_net_consumption_watts{site="home"} 300
+# HELP envoy_production_wh_total Energy produced, tolerant of meter resets.
+# TYPE envoy_production_wh_total counter
+envoy_production_wh_total{site="home"} 1.2e+06
+# HELP envoy_consumption_wh_total Energy consumed, tolerant of meter resets.
+# TYPE envoy_consumption_wh_total counter
+envoy_consumption_wh_total{site="home"} 900000
+# HELP envoy_inverter_watts Last power reported by the inverter.
+# TYPE envoy_inverter_watts gauge
+envoy_inverter_watts{serial="122012345678",site="home"} 240
+envoy_inverter_watts{serial="122012345679",site="home"} 245
+# HELP envoy_inverter_last_report_timestamp_seconds When the inverter last reported.
+# TYPE envoy_inverter_last_report_timestamp_seconds gauge
+envoy_inverter_last_report_timestamp_seconds{serial="122012345678",site="home"} 1.7172432e+09
+envoy_inverter_last_report_timestamp_seconds{serial="122012345679",site="home"} 1.71724321e+09
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(want),
+		"envoy_up", "envoy_production_watts", "envoy_consumption_watts", "envoy_net_consumption_watts",
+		"envoy_production_wh_total", "envoy_consumption_wh_total", "envoy_inverter_watts",
+		"envoy_inverter_last_report_timestamp_seconds")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorDown(t *testing.T) {
+	client := envoy.NewClient("127.0.0.1:1", envoy.WithProto("http"), envoy.WithRetryPolicy(envoy.NoRetry))
+	c := NewCollector(client, WithConstLabels(map[string]string{"site": "home"}))
+	want := `
+# HELP envoy_up Whether the last scrape of the Envoy succeeded.
+# TYPE envoy_up gauge
+envoy_up{site="home"} 0
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want), "envoy_up", "envoy_production_watts"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,105 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// provPath serves the installer device provisioning endpoints
+const provPath = "/prov"
+
+// DefaultProvisioningPollInterval is how often WaitForDiscovery checks the discovery status unless
+// WithProvisioningPollInterval says otherwise
+const DefaultProvisioningPollInterval = 10 * time.Second
+
+// WithProvisioningPollInterval sets how often WaitForDiscovery checks the discovery status. A zero or negative
+// interval keeps DefaultProvisioningPollInterval.
+func WithProvisioningPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		if interval > 0 {
+			c.provisionPoll = interval
+		}
+	}
+}
+
+// ProvisionedDevice is a device known to the provisioning endpoint
+type ProvisionedDevice struct {
+	SerialNum string `json:"serial_num,omitempty"`
+	// Status is e.g. pending, discovered or provisioned
+	Status string `json:"status,omitempty"`
+}
+
+// Discovery is the state of device discovery
+type Discovery struct {
+	// State is running while the Envoy is scanning for devices
+	State    string `json:"state,omitempty"`
+	Found    int    `json:"found,omitempty"`
+	Expected int    `json:"expected,omitempty"`
+}
+
+// ProvisioningStatus is the response of the provisioning endpoint
+type ProvisioningStatus struct {
+	Discovery Discovery           `json:"discovery,omitempty"`
+	PCUs      []ProvisionedDevice `json:"pcus,omitempty"`
+}
+
+// ProvisioningStatus returns the devices the Envoy has been told about and the discovery state. It requires an
+// installer token.
+func (c *Client) ProvisioningStatus(ctx context.Context) (ProvisioningStatus, error) {
+	var status ProvisioningStatus
+	err := c.get(ctx, provPath, &status)
+	return status, err
+}
+
+type provDevices struct {
+	PCUs []ProvisionedDevice `json:"pcus"`
+}
+
+// AddDevices provisions newly installed microinverters by serial number and starts discovering them. It requires an
+// installer token, and the action is subject to the Client's ConfirmFunc.
+func (c *Client) AddDevices(ctx context.Context, serials ...string) error {
+	if len(serials) == 0 {
+		return nil
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("add devices %s", strings.Join(serials, ", "))); err != nil {
+		return err
+	}
+	body := provDevices{}
+	for _, serial := range serials {
+		body.PCUs = append(body.PCUs, ProvisionedDevice{SerialNum: serial})
+	}
+	return c.call(ctx, http.MethodPost, provPath, body, nil)
+}
+
+// RemoveDevice deletes a retired microinverter. It requires an installer token, and the action is subject to the
+// Client's ConfirmFunc.
+func (c *Client) RemoveDevice(ctx context.Context, serial string) error {
+	if err := c.confirm(ctx, fmt.Sprintf("remove device %s", serial)); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodDelete, provPath+"/pcus/"+url.PathEscape(serial), nil, nil)
+}
+
+// WaitForDiscovery polls the provisioning status until discovery has finished, calling *progress*, if not nil, after
+// each poll. It returns the final status, or the context's error if *ctx* is done first.
+func (c *Client) WaitForDiscovery(ctx context.Context, progress func(ProvisioningStatus)) (ProvisioningStatus, error) {
+	for {
+		status, err := c.ProvisioningStatus(ctx)
+		if err != nil {
+			return status, err
+		}
+		if progress != nil {
+			progress(status)
+		}
+		if status.Discovery.State != "running" {
+			return status, nil
+		}
+		if err := sleep(ctx, c.provisionPoll); err != nil {
+			return status, err
+		}
+	}
+}
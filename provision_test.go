@@ -0,0 +1,32 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForDiscovery(t *testing.T) {
+	f := newFakeEnvoy(t)
+	var polls atomic.Int32
+	f.handle(provPath, func(w http.ResponseWriter, r *http.Request) {
+		state := "running"
+		if polls.Add(1) >= 3 {
+			state = "done"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"discovery":{"state":"` + state + `","found":2,"expected":2},"pcus":[]}`))
+	})
+
+	var updates int
+	c := f.client(WithProvisioningPollInterval(time.Millisecond))
+	status, err := c.WaitForDiscovery(context.Background(), func(ProvisioningStatus) { updates++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Discovery.State != "done" || updates != 3 {
+		t.Errorf("got %+v after %d updates, want discovery done after 3", status.Discovery, updates)
+	}
+}
@@ -0,0 +1,90 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the Client to *rps* requests per second on average, allowing bursts of up to *burst* requests.
+// The limit is shared by all endpoints that have no limit of their own from WithEndpointRateLimit.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter = newTokenBucket(rps, burst)
+	}
+}
+
+// WithEndpointRateLimit gives requests whose path starts with *path* their own limit of *rps* requests per second
+// with bursts of *burst*, instead of the limit set by WithRateLimit. When several prefixes match, the longest wins.
+func WithEndpointRateLimit(path string, rps float64, burst int) Option {
+	return func(c *Client) {
+		if c.endpointLimiters == nil {
+			c.endpointLimiters = make(map[string]*tokenBucket)
+		}
+		c.endpointLimiters[path] = newTokenBucket(rps, burst)
+	}
+}
+
+// limiterFor returns the limiter that applies to *req*, or nil if it is not limited
+func (c *Client) limiterFor(req *http.Request) *tokenBucket {
+	var match string
+	limiter := c.limiter
+	for prefix, l := range c.endpointLimiters {
+		if strings.HasPrefix(req.URL.Path, prefix) && len(prefix) > len(match) {
+			match, limiter = prefix, l
+		}
+	}
+	return limiter
+}
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a token is available or *ctx* is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil || b.rate <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// take the token now, going into debt if necessary, so waiters are served in order
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		// give the token back, since the request will not be made
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
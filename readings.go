@@ -0,0 +1,100 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Readings is the instantaneous state of the site reduced to the few numbers most applications need, whatever the
+// firmware and hardware
+type Readings struct {
+	Time time.Time
+	// Production is the solar production, from the production CT if there is one, otherwise the inverters
+	Production Watts
+	// Consumption is the power used by the site. It is only set when HasConsumption is.
+	Consumption Watts
+	// Net is the power drawn from the grid, negative while exporting. It is only set when HasConsumption is.
+	Net Watts
+	// HasConsumption is set when the site has consumption CTs
+	HasConsumption bool
+	// Battery is the power delivered by the batteries, negative while charging, and SOC their average state of
+	// charge in percent. They are only set when HasBattery is.
+	Battery    Watts
+	SOC        float64
+	HasBattery bool
+	// ProductionLifetime and ConsumptionLifetime are the meters' lifetime energy counters, zero if the Envoy does
+	// not report them. They can reset; see EnergyCounter.
+	ProductionLifetime  WattHours
+	ConsumptionLifetime WattHours
+}
+
+// Readings fetches the site's production, consumption, grid and battery power from whichever endpoints the
+// Envoy has, so callers need not branch on the hardware configuration
+func (c *Client) Readings(ctx context.Context) (Readings, error) {
+	p, err := c.Production(ctx)
+	if err != nil {
+		return Readings{Time: time.Now()}, err
+	}
+	r := readingsOf(p)
+	r.Time = time.Now()
+
+	batteries, err := c.EnsemblePower(ctx)
+	switch {
+	case err == nil:
+		var soc int
+		for _, b := range batteries {
+			r.Battery += b.Watts()
+			soc += b.SOC
+		}
+		r.SOC = float64(soc) / float64(len(batteries))
+		r.HasBattery = true
+	case errors.Is(err, ErrNotSupported):
+		// older AC batteries only report through production.json
+		for _, s := range p.Storage {
+			if s.Type == ProductionTypeACB && s.ActiveCount > 0 {
+				r.Battery += s.WNow
+				r.SOC = float64(s.PercentFull)
+				r.HasBattery = true
+			}
+		}
+	default:
+		return r, err
+	}
+	c.recordLatest(r, true)
+	return r, nil
+}
+
+// readingsOf returns the production, consumption and grid power reported by *p*
+func readingsOf(p Production) Readings {
+	var r Readings
+	r.Production, _ = currentWatts(p.Production)
+	r.ProductionLifetime = lifetimeOf(p.Production)
+	r.ConsumptionLifetime = lifetimeOf(consumptionOf(p, MeasurementTotalConsumption))
+	total, hasTotal := currentWatts(consumptionOf(p, MeasurementTotalConsumption))
+	net, hasNet := currentWatts(consumptionOf(p, MeasurementNetConsumption))
+	switch {
+	case hasTotal && hasNet:
+		r.Consumption, r.Net = total, net
+	case hasTotal:
+		r.Consumption, r.Net = total, total-r.Production
+	case hasNet:
+		r.Consumption, r.Net = net+r.Production, net
+	}
+	r.HasConsumption = hasTotal || hasNet
+	return r
+}
+
+// lifetimeOf returns the lifetime energy of *data*, preferring the production CT like currentWatts
+func lifetimeOf(data []ProductionData) WattHours {
+	var wh WattHours
+	for i, d := range data {
+		if d.Type == ProductionTypeEIM {
+			return d.WhLifetime
+		}
+		if i == 0 {
+			wh = d.WhLifetime
+		}
+	}
+	return wh
+}
@@ -0,0 +1,168 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// DeviceLabel is a friendly name and group assigned to a device
+type DeviceLabel struct {
+	// Name is e.g. "Garage roof, row 2, panel 3"
+	Name string `json:"name,omitempty"`
+	// Group is e.g. "East array"
+	Group string `json:"group,omitempty"`
+}
+
+// LabelStore persists device labels across process restarts
+type LabelStore interface {
+	// Load returns the saved labels by serial number, or none if nothing was saved
+	Load(ctx context.Context) (map[string]DeviceLabel, error)
+	// Save replaces the saved labels
+	Save(ctx context.Context, labels map[string]DeviceLabel) error
+}
+
+// LabelFile is a LabelStore that keeps the labels as JSON in a file
+type LabelFile string
+
+// Load reads the labels from the file, returning none if it does not exist
+func (f LabelFile) Load(ctx context.Context) (map[string]DeviceLabel, error) {
+	b, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var labels map[string]DeviceLabel
+	if err := json.Unmarshal(b, &labels); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", string(f), err)
+	}
+	return labels, nil
+}
+
+// Save writes the labels to a temporary file and renames it into place, so a crash never leaves a truncated file
+func (f LabelFile) Save(ctx context.Context, labels map[string]DeviceLabel) error {
+	b, err := json.MarshalIndent(labels, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(string(f), b)
+}
+
+// DeviceRegistry assigns friendly names and groups to device serial numbers
+type DeviceRegistry struct {
+	store LabelStore
+
+	mu     sync.RWMutex
+	labels map[string]DeviceLabel
+}
+
+// NewDeviceRegistry creates a DeviceRegistry holding the labels saved in *store*. With a nil *store* the labels only
+// live in memory.
+func NewDeviceRegistry(ctx context.Context, store LabelStore) (*DeviceRegistry, error) {
+	r := &DeviceRegistry{store: store, labels: make(map[string]DeviceLabel)}
+	if store == nil {
+		return r, nil
+	}
+	labels, err := store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for serial, l := range labels {
+		r.labels[serial] = l
+	}
+	return r, nil
+}
+
+// Label returns the label of the device with serial number *serial*
+func (r *DeviceRegistry) Label(serial string) (DeviceLabel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	l, ok := r.labels[serial]
+	return l, ok
+}
+
+// Name returns the friendly name of the device with serial number *serial*, or the serial number if it has none
+func (r *DeviceRegistry) Name(serial string) string {
+	if l, ok := r.Label(serial); ok && l.Name != "" {
+		return l.Name
+	}
+	return serial
+}
+
+// Group returns the serial numbers of the devices in *group*, sorted
+func (r *DeviceRegistry) Group(group string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var serials []string
+	for serial, l := range r.labels {
+		if l.Group == group {
+			serials = append(serials, serial)
+		}
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+// Set labels the device with serial number *serial* and saves the labels. If saving fails, the label is left as
+// it was.
+func (r *DeviceRegistry) Set(ctx context.Context, serial string, label DeviceLabel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, had := r.labels[serial]
+	r.labels[serial] = label
+	return r.save(ctx, serial, old, had)
+}
+
+// Remove drops the label of the device with serial number *serial* and saves the labels. If saving fails, the
+// label is kept.
+func (r *DeviceRegistry) Remove(ctx context.Context, serial string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, had := r.labels[serial]
+	delete(r.labels, serial)
+	return r.save(ctx, serial, old, had)
+}
+
+// save persists the labels, restoring the label of *serial* to *old*, or to none unless *had*, if that fails; the
+// caller holds mu
+func (r *DeviceRegistry) save(ctx context.Context, serial string, old DeviceLabel, had bool) error {
+	if r.store == nil {
+		return nil
+	}
+	err := r.store.Save(ctx, r.labels)
+	if err == nil {
+		return nil
+	}
+	if had {
+		r.labels[serial] = old
+	} else {
+		delete(r.labels, serial)
+	}
+	return err
+}
+
+// Labeled is a value returned by the Client along with the label of the device it concerns
+type Labeled[T any] struct {
+	Value  T
+	Serial string
+	Label  DeviceLabel
+}
+
+// Annotate labels each of *items*, whose serial number is given by *serial*, e.g.
+//
+//	envoy.Annotate(registry, inverters, func(i envoy.Inverter) string { return i.SerialNumber })
+func Annotate[T any](r *DeviceRegistry, items []T, serial func(T) string) []Labeled[T] {
+	labeled := make([]Labeled[T], len(items))
+	for i, item := range items {
+		s := serial(item)
+		l, _ := r.Label(s)
+		labeled[i] = Labeled[T]{Value: item, Serial: s, Label: l}
+	}
+	return labeled
+}
@@ -0,0 +1,73 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// failingLabels is a LabelStore whose saves fail once failing is set
+type failingLabels struct {
+	failing bool
+}
+
+func (s *failingLabels) Load(ctx context.Context) (map[string]DeviceLabel, error) { return nil, nil }
+
+func (s *failingLabels) Save(ctx context.Context, labels map[string]DeviceLabel) error {
+	if s.failing {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func TestLabelFile(t *testing.T) {
+	ctx := context.Background()
+	f := LabelFile(filepath.Join(t.TempDir(), "labels.json"))
+	r, err := NewDeviceRegistry(ctx, f)
+	if err != nil {
+		t.Fatalf("a missing file should load as no labels: %v", err)
+	}
+	if err := r.Set(ctx, "1", DeviceLabel{Name: "Garage", Group: "East"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewDeviceRegistry(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Name("1"); got != "Garage" {
+		t.Errorf("reloaded name %q, want Garage", got)
+	}
+}
+
+func TestRegistryRollsBackFailedSave(t *testing.T) {
+	ctx := context.Background()
+	store := &failingLabels{}
+	r, err := NewDeviceRegistry(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(ctx, "1", DeviceLabel{Name: "Garage"}); err != nil {
+		t.Fatal(err)
+	}
+	store.failing = true
+
+	if err := r.Set(ctx, "1", DeviceLabel{Name: "Shed"}); err == nil {
+		t.Fatal("Set succeeded despite the failed save")
+	}
+	if got := r.Name("1"); got != "Garage" {
+		t.Errorf("name %q after a failed Set, want Garage", got)
+	}
+	if err := r.Set(ctx, "2", DeviceLabel{Name: "Shed"}); err == nil {
+		t.Fatal("Set succeeded despite the failed save")
+	}
+	if _, ok := r.Label("2"); ok {
+		t.Error("new label kept after a failed Set")
+	}
+	if err := r.Remove(ctx, "1"); err == nil {
+		t.Fatal("Remove succeeded despite the failed save")
+	}
+	if got := r.Name("1"); got != "Garage" {
+		t.Errorf("name %q after a failed Remove, want Garage", got)
+	}
+}
@@ -0,0 +1,61 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// reportSettingsPath serves the settings for reporting to Enlighten
+const reportSettingsPath = "/ivp/peb/reportsettings"
+
+// CapabilityReportSettings is the ability to change the report settings locally. It is not detected from Info; it
+// is reported when firmware rejects UpdateReportSettings.
+const CapabilityReportSettings Capability = "report-settings"
+
+// ReportSettings is how and how often the Envoy reports to Enlighten. The fields carry no omitempty so they can be
+// written back.
+type ReportSettings struct {
+	// IntervalSeconds is how often reports are sent, typically 900
+	IntervalSeconds int `json:"report_interval"`
+	// Host and Port are the reporting destination
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// TLS reports whether reports are sent over TLS
+	TLS bool `json:"tls"`
+	// Enabled reports whether reporting is on at all
+	Enabled bool `json:"enabled"`
+}
+
+// ReportSettings returns the reporting interval and destination, e.g. to diagnose why Enlighten lags local data
+func (c *Client) ReportSettings(ctx context.Context) (ReportSettings, error) {
+	var settings ReportSettings
+	err := c.get(ctx, reportSettingsPath, &settings)
+	return settings, err
+}
+
+// UpdateReportSettings changes the report settings, preserving fields the package does not model. Firmware that
+// does not allow local changes returns a *NotSupportedError for CapabilityReportSettings. The action is subject to
+// the Client's ConfirmFunc.
+func (c *Client) UpdateReportSettings(ctx context.Context, settings ReportSettings) error {
+	if settings.IntervalSeconds <= 0 {
+		return fmt.Errorf("invalid report interval %d s", settings.IntervalSeconds)
+	}
+	var doc rawObject
+	if err := c.get(ctx, reportSettingsPath, &doc); err != nil {
+		return err
+	}
+	if err := mergeInto(doc, settings); err != nil {
+		return err
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("set report settings %s:%d every %d s", settings.Host, settings.Port, settings.IntervalSeconds)); err != nil {
+		return err
+	}
+	err := c.call(ctx, http.MethodPut, reportSettingsPath, doc, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed) {
+		return &NotSupportedError{Capability: CapabilityReportSettings, Err: err}
+	}
+	return err
+}
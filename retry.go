@@ -0,0 +1,197 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the Client retries requests that fail transiently, e.g. the 503s Envoys return while
+// uploading their nightly reports.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry. It doubles on every further retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes each wait by up to this fraction in either direction, between 0 and 1
+	Jitter float64
+	// RetryableStatus lists the HTTP status codes that are retried
+	RetryableStatus []int
+	// RetryNonIdempotent allows retrying methods such as POST, which may repeat their side effects
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy is the RetryPolicy a Client uses unless WithRetryPolicy says otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	InitialBackoff:  500 * time.Millisecond,
+	MaxBackoff:      10 * time.Second,
+	Jitter:          0.2,
+	RetryableStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// NoRetry is a RetryPolicy that never retries
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// WithRetryPolicy sets the policy for retrying transient failures. The default is DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// retryable reports whether *req* may be retried after receiving *resp* or *err*
+func (p RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if !p.RetryNonIdempotent && !idempotent(req.Method) {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return transient(err)
+	}
+	for _, status := range p.RetryableStatus {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// transient reports whether the transport error *err* may go away on its own: a timeout, or a connection that was
+// refused or reset, e.g. while the Envoy reboots. Certificate and pinning failures, canceled contexts and the like
+// would only fail again.
+func transient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// backoff returns how long to wait before retry number *retry*, counting from 1
+func (p RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	// honor a Retry-After given in seconds if it asks for a longer wait
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(secs)*time.Second > d {
+			d = time.Duration(secs) * time.Second
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Backoff returns how long to wait before retry number *retry*, counting from 1, for callers retrying their own
+// requests with the policy
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	return p.backoff(retry, nil)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// do sends *req*, retrying transient failures according to the Client's RetryPolicy. Every attempt is subject to the
+// Client's rate limits, and the call as a whole to its circuit breaker. Compression is left to http.Transport, which
+// requests gzip and decodes it transparently.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.attempt(req)
+	if errors.Is(req.Context().Err(), context.Canceled) {
+		// a call abandoned by the caller says nothing about the Envoy's health
+		c.breaker.release()
+	} else {
+		c.breaker.record(resp, err)
+	}
+	return resp, err
+}
+
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	for key, values := range c.headers {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+	limiter := c.limiterFor(req)
+	for attempt := 1; ; attempt++ {
+		if err := limiter.wait(req.Context()); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		// http.Client adds the jar's cookies to the request it is given, so send a copy to keep a stale session
+		// cookie from sticking to the request when it is sent again after logging in
+		resp, err := c.client.Do(req.Clone(req.Context()))
+		elapsed := time.Since(start)
+		c.logRequest(req, resp, err, elapsed)
+		c.stats.record(attempt, resp, err, elapsed)
+		if attempt >= c.retry.MaxAttempts || !c.retry.retryable(req, resp, err) {
+			return resp, err
+		}
+		wait := c.retry.backoff(attempt, resp)
+		c.logger.DebugContext(req.Context(), "retrying request", "method", req.Method, "path", req.URL.Path,
+			"attempt", attempt, "wait", wait)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// sleep waits for *d* or until *ctx* is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) logRequest(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	if err != nil {
+		c.logger.DebugContext(req.Context(), "request failed", "method", req.Method, "path", req.URL.Path,
+			"duration", elapsed, "error", err)
+		return
+	}
+	c.logger.DebugContext(req.Context(), "request", "method", req.Method, "path", req.URL.Path,
+		"status", resp.StatusCode, "duration", elapsed)
+}
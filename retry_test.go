@@ -0,0 +1,55 @@
+package envoy
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestTransient(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, true},
+		{&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{&net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{fmt.Errorf("tls: %w", ErrCertificateMismatch), false},
+		{x509.UnknownAuthorityError{}, false},
+		{context.Canceled, false},
+	} {
+		if got := transient(tc.err); got != tc.want {
+			t.Errorf("transient(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	srv.Close()
+	c := NewClient(addr, WithProto("http"), WithRetryPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+	if _, err := c.Info(context.Background()); err == nil {
+		t.Fatal("got no error from a closed server")
+	}
+	if s := c.Stats(); s.Requests != 3 || s.Retries != 2 {
+		t.Errorf("sent %d requests with %d retries, want 3 with 2", s.Requests, s.Retries)
+	}
+}
@@ -0,0 +1,73 @@
+package envoy
+
+import "time"
+
+// Sample is one measurement of the site, or of a single inverter, flattened into the common record the exporters
+// write
+type Sample struct {
+	Time time.Time
+	// Source is the name of the Poller source the sample came from
+	Source string
+	// Serial is the serial number of the inverter, or empty for the whole site
+	Serial string
+	// Production is the solar production
+	Production Watts
+	// Consumption and Net are the power used by the site and drawn from the grid. They are only set when
+	// HasConsumption is.
+	Consumption    Watts
+	Net            Watts
+	HasConsumption bool
+	// Battery and SOC are the battery power and state of charge. They are only set when HasBattery is.
+	Battery    Watts
+	SOC        float64
+	HasBattery bool
+}
+
+// Samples flattens the value of *u* into Samples. Readings and Production give one sample of the site and a
+// []Inverter one sample per inverter, timed by its last report, or by the Update if it has never reported. Other
+// values and failed polls give none.
+func Samples(u Update) []Sample {
+	if u.Err != nil {
+		return nil
+	}
+	switch v := u.Value.(type) {
+	case Readings:
+		return []Sample{sampleOf(u, v)}
+	case Production:
+		return []Sample{sampleOf(u, readingsOf(v))}
+	case []Inverter:
+		samples := make([]Sample, len(v))
+		for i, inv := range v {
+			t := inv.LastReportDate.Time
+			if t.IsZero() {
+				t = u.Time
+			}
+			samples[i] = Sample{
+				Time:       t,
+				Source:     u.Source,
+				Serial:     inv.SerialNumber,
+				Production: Watts(inv.LastReportWatts),
+			}
+		}
+		return samples
+	}
+	return nil
+}
+
+func sampleOf(u Update, r Readings) Sample {
+	t := r.Time
+	if t.IsZero() {
+		t = u.Time
+	}
+	return Sample{
+		Time:           t,
+		Source:         u.Source,
+		Production:     r.Production,
+		Consumption:    r.Consumption,
+		Net:            r.Net,
+		HasConsumption: r.HasConsumption,
+		Battery:        r.Battery,
+		SOC:            r.SOC,
+		HasBattery:     r.HasBattery,
+	}
+}
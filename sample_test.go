@@ -0,0 +1,24 @@
+package envoy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSamplesInverterTime(t *testing.T) {
+	polled := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reported := polled.Add(-time.Minute)
+	samples := Samples(Update{Source: "inverters", Time: polled, Value: []Inverter{
+		{SerialNumber: "1", LastReportDate: Timestamp{reported}, LastReportWatts: 250},
+		{SerialNumber: "2"},
+	}})
+	if len(samples) != 2 {
+		t.Fatalf("got %d samples, want 2", len(samples))
+	}
+	if !samples[0].Time.Equal(reported) {
+		t.Errorf("inverter 1 sampled at %v, want its report at %v", samples[0].Time, reported)
+	}
+	if !samples[1].Time.Equal(polled) {
+		t.Errorf("inverter 2, which never reported, sampled at %v, want the poll at %v", samples[1].Time, polled)
+	}
+}
@@ -0,0 +1,27 @@
+package envoy
+
+import "context"
+
+// Sink persists or exports polled Updates, e.g. the SQLite store in envoy/store/sqlite
+type Sink interface {
+	// Write records *u*. Updates of failed polls are passed too, so a Sink may record or skip them.
+	Write(ctx context.Context, u Update) error
+}
+
+// Drain writes the Updates from *updates*, e.g. a Poller subscription, to *sink* until *updates* is closed or *ctx*
+// is done. It stops at the first error from *sink* and returns it.
+func Drain(ctx context.Context, updates <-chan Update, sink Sink) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case u, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := sink.Write(ctx, u); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Snapshot is the state of the whole system fetched at one moment
+type Snapshot struct {
+	Time       time.Time
+	Production Production
+	// Meters is empty on systems without CTs
+	Meters    []MeterReading
+	Inverters []Inverter
+	Inventory Inventory
+	// Ensemble is empty on systems without batteries or a system controller
+	Ensemble []EnsembleGroup
+	Home     Home
+	// Errors holds the error of each part that could not be fetched, keyed by field name. Parts the system does not
+	// support are left empty without an error.
+	Errors map[string]error
+}
+
+// Snapshot fetches production, meters, inverters, inventory, ensemble and home data concurrently. On partial
+// failure it returns what was fetched, with the failures in Errors and joined into the returned error.
+func (c *Client) Snapshot(ctx context.Context) (Snapshot, error) {
+	s := Snapshot{Time: time.Now(), Errors: make(map[string]error)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	fetch := func(name string, f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil && !errors.Is(err, ErrNotSupported) {
+				mu.Lock()
+				s.Errors[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	// each part is written by one goroutine only, so only Errors needs the lock
+	fetch("Production", func() (err error) { s.Production, err = c.Production(ctx); return })
+	fetch("Meters", func() (err error) { s.Meters, err = c.MeterReadings(ctx); return })
+	fetch("Inverters", func() (err error) { s.Inverters, err = c.Inverters(ctx); return })
+	fetch("Inventory", func() (err error) { s.Inventory, err = c.Inventory(ctx, InventoryOptions{}); return })
+	fetch("Ensemble", func() (err error) { s.Ensemble, err = c.EnsembleInventory(ctx); return })
+	fetch("Home", func() (err error) { s.Home, err = c.Home(ctx); return })
+	wg.Wait()
+
+	if len(s.Errors) == 0 {
+		return s, nil
+	}
+	names := make([]string, 0, len(s.Errors))
+	for name := range s.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s: %w", name, s.Errors[name]))
+	}
+	return s, errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package envoy
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ClientStats counts the HTTP requests a Client has sent since it was created
+type ClientStats struct {
+	// Requests is the number of requests sent, counting each retry
+	Requests uint64
+	// Retries is how many of the requests were retries
+	Retries uint64
+	// Failures is how many got no response, e.g. timeouts and refused connections
+	Failures uint64
+	// ErrorResponses is how many were answered with a status of 400 or above
+	ErrorResponses uint64
+	// Duration is the total time spent waiting for responses
+	Duration time.Duration
+}
+
+type clientStats struct {
+	requests, retries, failures, errorResponses atomic.Uint64
+	duration                                    atomic.Int64
+}
+
+// record counts attempt number *attempt* of a request, which got *resp* or *err* after *elapsed*
+func (s *clientStats) record(attempt int, resp *http.Response, err error, elapsed time.Duration) {
+	s.requests.Add(1)
+	if attempt > 1 {
+		s.retries.Add(1)
+	}
+	switch {
+	case err != nil:
+		s.failures.Add(1)
+	case resp.StatusCode >= http.StatusBadRequest:
+		s.errorResponses.Add(1)
+	}
+	s.duration.Add(int64(elapsed))
+}
+
+// Stats returns the request counters of the Client, e.g. for metrics
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Requests:       c.stats.requests.Load(),
+		Retries:        c.stats.retries.Load(),
+		Failures:       c.stats.failures.Load(),
+		ErrorResponses: c.stats.errorResponses.Load(),
+		Duration:       time.Duration(c.stats.duration.Load()),
+	}
+}
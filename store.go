@@ -0,0 +1,129 @@
+package envoy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrTokenNotFound is returned by a TokenStore that has no token saved
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrKeyringUnsupported is returned by KeyringStore on platforms without a supported keyring
+	ErrKeyringUnsupported = errors.New("keyring not supported on this platform")
+)
+
+// TokenStore persists tokens across process restarts
+type TokenStore interface {
+	// Load returns the saved token, or ErrTokenNotFound if there is none
+	Load(ctx context.Context) (string, error)
+	// Save replaces the saved token
+	Save(ctx context.Context, token string) error
+}
+
+// FileStore is a TokenStore that keeps the token in a file readable only by the current user
+type FileStore string
+
+// Load reads the token from the file
+func (f FileStore) Load(ctx context.Context) (string, error) {
+	b, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrTokenNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", ErrTokenNotFound
+	}
+	return token, nil
+}
+
+// Save writes the token to a temporary file and renames it into place, so a crash never leaves a truncated token behind
+func (f FileStore) Save(ctx context.Context, token string) error {
+	return writeFileAtomic(string(f), []byte(token))
+}
+
+// writeFileAtomic writes *b* to a temporary file readable only by the user and renames it over *path*
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// KeyringStore is a TokenStore backed by the operating system's keyring: the login keychain on macOS and the Secret
+// Service (via secret-tool) on Linux. Other platforms return ErrKeyringUnsupported.
+type KeyringStore struct {
+	// Service and User identify the keyring entry
+	Service string
+	User    string
+}
+
+// Load reads the token from the keyring
+func (k KeyringStore) Load(ctx context.Context) (string, error) {
+	return keyringGet(ctx, k.Service, k.User)
+}
+
+// Save writes the token to the keyring, replacing any existing entry
+func (k KeyringStore) Save(ctx context.Context, token string) error {
+	return keyringSet(ctx, k.Service, k.User, token)
+}
+
+// StoredToken is a TokenProvider that serves the token saved in Store while it remains valid, and otherwise fetches a
+// new one from Provider and saves it, so that restarting a process does not request a new token from Enlighten.
+type StoredToken struct {
+	Store    TokenStore
+	Provider TokenProvider
+	// Margin is how long before expiry a stored token is considered stale. Zero means DefaultTokenRefreshMargin.
+	Margin time.Duration
+}
+
+// Token returns the stored token if it is still fresh, or fetches and saves a new one. Under a context from
+// ForceRefresh the stored token is ignored.
+func (s StoredToken) Token(ctx context.Context) (string, error) {
+	margin := s.Margin
+	if margin == 0 {
+		margin = DefaultTokenRefreshMargin
+	}
+	var token string
+	err := ErrTokenNotFound
+	if !forceRefresh(ctx) {
+		token, err = s.Store.Load(ctx)
+	}
+	if err != nil && !errors.Is(err, ErrTokenNotFound) {
+		return "", err
+	}
+	if err == nil {
+		expiry := tokenExpiry(token)
+		if expiry.IsZero() || time.Until(expiry) > margin {
+			return token, nil
+		}
+	}
+
+	token, err = s.Provider.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Store.Save(ctx, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
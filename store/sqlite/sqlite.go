@@ -0,0 +1,176 @@
+// Package sqlite persists polled samples and energy totals in a SQLite database, so small monitoring applications
+// keep their history across restarts.
+//
+// The package works with any database/sql SQLite driver, which the application imports and opens itself:
+//
+//	import _ "modernc.org/sqlite"
+//
+//	db, err := sql.Open("sqlite", "/var/lib/envoy/history.db")
+//	store, err := sqlite.NewStore(ctx, db)
+//	go envoy.Drain(ctx, poller.Subscribe(16), store)
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gcochard/go-envoy"
+)
+
+// Periods of the energy totals
+const (
+	PeriodHourly = "hourly"
+	PeriodDaily  = "daily"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS samples (
+	source TEXT NOT NULL,
+	time INTEGER NOT NULL,
+	value TEXT,
+	error TEXT
+);
+CREATE INDEX IF NOT EXISTS samples_source_time ON samples (source, time);
+CREATE TABLE IF NOT EXISTS totals (
+	period TEXT NOT NULL,
+	start_time INTEGER NOT NULL,
+	end_time INTEGER NOT NULL,
+	location TEXT NOT NULL,
+	production REAL NOT NULL,
+	consumption REAL NOT NULL,
+	import REAL NOT NULL,
+	export REAL NOT NULL,
+	PRIMARY KEY (period, start_time)
+);`
+
+// Store is an envoy.Sink keeping Updates, and the totals of an envoy.Aggregator, in a SQLite database. Times are
+// stored as Unix nanoseconds and values as JSON.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore creates the tables of a Store in *db* if they do not exist. The caller keeps ownership of *db*.
+func NewStore(ctx context.Context, db *sql.DB) (*Store, error) {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Write records *u*, including the error of a failed poll
+func (s *Store) Write(ctx context.Context, u envoy.Update) error {
+	var value, msg sql.NullString
+	if u.Value != nil {
+		b, err := json.Marshal(u.Value)
+		if err != nil {
+			return err
+		}
+		value = sql.NullString{String: string(b), Valid: true}
+	}
+	if u.Err != nil {
+		msg = sql.NullString{String: u.Err.Error(), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO samples (source, time, value, error) VALUES (?, ?, ?, ?)`,
+		u.Source, u.Time.UnixNano(), value, msg)
+	return err
+}
+
+// Load returns the Updates of *source* recorded from *from* up to but excluding *to*, oldest first, with their
+// values decoded as T. They can be replayed into e.g. an envoy.History after a restart.
+func Load[T any](ctx context.Context, s *Store, source string, from, to time.Time) ([]envoy.Update, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT time, value, error FROM samples
+		WHERE source = ? AND time >= ? AND time < ? ORDER BY time`, source, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var updates []envoy.Update
+	for rows.Next() {
+		var t int64
+		var value, msg sql.NullString
+		if err := rows.Scan(&t, &value, &msg); err != nil {
+			return nil, err
+		}
+		u := envoy.Update{Source: source, Time: time.Unix(0, t)}
+		if value.Valid {
+			var v T
+			if err := json.Unmarshal([]byte(value.String), &v); err != nil {
+				return nil, err
+			}
+			u.Value = v
+		}
+		if msg.Valid {
+			u.Err = errors.New(msg.String)
+		}
+		updates = append(updates, u)
+	}
+	return updates, rows.Err()
+}
+
+// Prune deletes the samples recorded before *before*
+func (s *Store) Prune(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM samples WHERE time < ?`, before.UnixNano())
+	return err
+}
+
+// SaveTotals records the *totals* of *period*, e.g. PeriodHourly and Aggregator.Hourly, replacing those saved
+// earlier for the same periods
+func (s *Store) SaveTotals(ctx context.Context, period string, totals []envoy.EnergyTotals) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, t := range totals {
+		_, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO totals
+			(period, start_time, end_time, location, production, consumption, import, export)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			period, t.Start.UnixNano(), t.End.UnixNano(), t.Start.Location().String(),
+			float64(t.Production), float64(t.Consumption), float64(t.Import), float64(t.Export))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Totals returns the totals of *period* starting from *from* up to but excluding *to*, oldest first. Their times
+// are in the location they were aggregated in, if it can be loaded, otherwise UTC.
+func (s *Store) Totals(ctx context.Context, period string, from, to time.Time) ([]envoy.EnergyTotals, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT start_time, end_time, location, production, consumption, import, export
+		FROM totals WHERE period = ? AND start_time >= ? AND start_time < ? ORDER BY start_time`,
+		period, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	locations := make(map[string]*time.Location)
+	var totals []envoy.EnergyTotals
+	for rows.Next() {
+		var start, end int64
+		var name string
+		var production, consumption, imported, exported float64
+		if err := rows.Scan(&start, &end, &name, &production, &consumption, &imported, &exported); err != nil {
+			return nil, err
+		}
+		loc, ok := locations[name]
+		if !ok {
+			if loc, err = time.LoadLocation(name); err != nil {
+				loc = time.UTC
+			}
+			locations[name] = loc
+		}
+		totals = append(totals, envoy.EnergyTotals{
+			Start:       time.Unix(0, start).In(loc),
+			End:         time.Unix(0, end).In(loc),
+			Production:  envoy.WattHours(production),
+			Consumption: envoy.WattHours(consumption),
+			Import:      envoy.WattHours(imported),
+			Export:      envoy.WattHours(exported),
+		})
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,2 @@
+// Package sqlitetest holds the tests of package sqlite that need a SQLite driver, here modernc.org/sqlite
+package sqlitetest
@@ -0,0 +1,24 @@
+// Module sqlitetest tests the sqlite store against a real driver without making the client depend on one. It is
+// never published, so it always builds against the store in this checkout.
+module github.com/gcochard/go-envoy/store/sqlite/sqlitetest
+
+go 1.26.0
+
+replace github.com/gcochard/go-envoy => ../../..
+
+require (
+	github.com/gcochard/go-envoy v0.0.0-00010101000000-000000000000
+	modernc.org/sqlite v1.60.0
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.24 // indirect
+	github.com/ncruces/go-strftime v1.0.0 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.48.0 // indirect
+	modernc.org/libc v1.77.1 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.12.1 // indirect
+)
@@ -0,0 +1,144 @@
+package sqlitetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gcochard/go-envoy"
+	"github.com/gcochard/go-envoy/store/sqlite"
+	_ "modernc.org/sqlite"
+)
+
+func newStore(t *testing.T) *sqlite.Store {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, err := sqlite.NewStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSamples(t *testing.T) {
+	store := newStore(t)
+	ctx := context.Background()
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		at := start.Add(time.Duration(i) * time.Minute)
+		u := envoy.Update{Source: "readings", Time: at, Value: envoy.Readings{Time: at, Production: envoy.Watts(100 * i)}}
+		if i == 2 {
+			u.Value, u.Err = nil, errors.New("envoy unreachable")
+		}
+		if err := store.Write(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Write(ctx, envoy.Update{Source: "inverters", Time: start, Value: []envoy.Inverter{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := sqlite.Load[envoy.Readings](ctx, store, "readings", start, start.Add(3*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 3 {
+		t.Fatalf("loaded %d updates, want the 3 readings before the end", len(updates))
+	}
+	for i, u := range updates {
+		if want := start.Add(time.Duration(i) * time.Minute); !u.Time.Equal(want) || u.Source != "readings" {
+			t.Errorf("update %d is %s at %v, want readings at %v", i, u.Source, u.Time, want)
+		}
+	}
+	if r, ok := updates[1].Value.(envoy.Readings); !ok || r.Production != 100 || !r.Time.Equal(start.Add(time.Minute)) {
+		t.Errorf("got %#v, want the second readings", updates[1].Value)
+	}
+	if u := updates[2]; u.Value != nil || u.Err == nil || u.Err.Error() != "envoy unreachable" {
+		t.Errorf("got %#v, want the failed poll", u)
+	}
+
+	if err := store.Prune(ctx, start.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	updates, err = sqlite.Load[envoy.Readings](ctx, store, "readings", time.Time{}, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 2 || !updates[0].Time.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("got %d updates after pruning, want the last 2", len(updates))
+	}
+}
+
+func TestTotalsAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	store := newStore(t)
+	ctx := context.Background()
+
+	// a steady 1 kW through the day clocks fall back, and into the next
+	a := envoy.Aggregator{Location: loc}
+	from := time.Date(2024, 11, 3, 0, 0, 0, 0, loc)
+	to := time.Date(2024, 11, 4, 1, 0, 0, 0, loc)
+	for at := from; !at.After(to); at = at.Add(5 * time.Minute) {
+		a.Add(envoy.Readings{Time: at, Production: 1000})
+	}
+	hourly, daily := a.Hourly(), a.Daily()
+	if err := store.SaveTotals(ctx, sqlite.PeriodHourly, hourly); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveTotals(ctx, sqlite.PeriodDaily, daily); err != nil {
+		t.Fatal(err)
+	}
+
+	days, err := store.Totals(ctx, sqlite.PeriodDaily, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	if d := days[0]; d.End.Sub(d.Start) != 25*time.Hour || math.Abs(float64(d.Production)-25000) > 1e-6 {
+		t.Errorf("got the fall-back day from %v to %v producing %v, want 25 h and 25000 Wh", d.Start, d.End, d.Production)
+	}
+	for i, d := range days {
+		if d.Start.Location().String() != loc.String() || !d.Start.Equal(daily[i].Start) || !d.End.Equal(daily[i].End) {
+			t.Errorf("day %d is %v to %v, want %v to %v in %v", i, d.Start, d.End, daily[i].Start, daily[i].End, loc)
+		}
+	}
+
+	hours, err := store.Totals(ctx, sqlite.PeriodHourly, from, from.Add(25*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hours) != 25 {
+		t.Fatalf("got %d hours on the fall-back day, want 25", len(hours))
+	}
+	// the repeated 1am hour is kept twice, an hour apart
+	if first, second := hours[1], hours[2]; first.Start.Hour() != 1 || second.Start.Hour() != 1 ||
+		second.Start.Sub(first.Start) != time.Hour {
+		t.Errorf("got hours starting %v and %v, want both 1am occurrences", first.Start, second.Start)
+	}
+
+	// saving a period again replaces it
+	replaced := days[1]
+	replaced.Production = 1
+	if err := store.SaveTotals(ctx, sqlite.PeriodDaily, []envoy.EnergyTotals{replaced}); err != nil {
+		t.Fatal(err)
+	}
+	days, err = store.Totals(ctx, sqlite.PeriodDaily, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[1].Production != 1 {
+		t.Errorf("got %+v, want the second day replaced", days)
+	}
+}
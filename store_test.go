@@ -0,0 +1,39 @@
+package envoy
+
+import (
+	"context"
+	"testing"
+)
+
+// memoryStore is a TokenStore holding the token in memory
+type memoryStore struct {
+	token string
+}
+
+func (m *memoryStore) Load(ctx context.Context) (string, error) {
+	if m.token == "" {
+		return "", ErrTokenNotFound
+	}
+	return m.token, nil
+}
+
+func (m *memoryStore) Save(ctx context.Context, token string) error {
+	m.token = token
+	return nil
+}
+
+func TestStoredTokenForceRefresh(t *testing.T) {
+	store := &memoryStore{token: "stored"}
+	s := StoredToken{Store: store, Provider: StaticToken("fresh")}
+	ctx := context.Background()
+
+	if token, err := s.Token(ctx); err != nil || token != "stored" {
+		t.Fatalf("got %q, %v, want the stored token", token, err)
+	}
+	if token, err := s.Token(ForceRefresh(ctx)); err != nil || token != "fresh" {
+		t.Fatalf("got %q, %v, want a fresh token when forced", token, err)
+	}
+	if store.token != "fresh" {
+		t.Errorf("stored %q, want the fresh token saved", store.token)
+	}
+}
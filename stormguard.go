@@ -0,0 +1,54 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// stormGuardPath serves the Storm Guard setting on firmware that exposes it locally
+const stormGuardPath = "/ivp/ss/stormguard"
+
+// StormGuard is the Storm Guard setting, which charges the batteries to full when Enphase forecasts severe weather
+type StormGuard struct {
+	// State is enabled or disabled
+	State string `json:"stormguard_state,omitempty"`
+	// StormAlert is set while a severe weather alert is active
+	StormAlert bool `json:"storm_alert,omitempty"`
+}
+
+// Enabled reports whether Storm Guard is enabled
+func (s StormGuard) Enabled() bool {
+	return s.State == "enabled"
+}
+
+// StormGuard returns the Storm Guard setting. Systems without batteries return a *NotSupportedError for
+// CapabilityEncharge.
+func (c *Client) StormGuard(ctx context.Context) (StormGuard, error) {
+	var sg StormGuard
+	err := c.getFeature(ctx, CapabilityEncharge, stormGuardPath, &sg)
+	return sg, err
+}
+
+// SetStormGuard enables or disables Storm Guard, e.g. when it conflicts with local optimization, then reads the
+// setting back, returning ErrNotApplied if it did not take. The action is subject to the Client's ConfirmFunc.
+func (c *Client) SetStormGuard(ctx context.Context, enabled bool) error {
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	if err := c.confirm(ctx, fmt.Sprintf("set storm guard %s", state)); err != nil {
+		return err
+	}
+	if err := c.call(ctx, http.MethodPut, stormGuardPath, StormGuard{State: state}, nil); err != nil {
+		return err
+	}
+	sg, err := c.StormGuard(ctx)
+	if err != nil {
+		return err
+	}
+	if sg.Enabled() != enabled {
+		return fmt.Errorf("%w: storm guard is %s", ErrNotApplied, sg.State)
+	}
+	return nil
+}
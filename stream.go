@@ -0,0 +1,187 @@
+package envoy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// maxStreamEvent caps the size of one server-sent event
+const maxStreamEvent = 1 << 20
+
+// eventStream is the media type of a server-sent event stream
+const eventStream = "text/event-stream"
+
+// ReconnectPolicy controls how StreamMeter and KeepLiveDataStreaming recover from a dropped connection. Unlike a
+// RetryPolicy, which bounds the attempts of a single request, it bounds a run of consecutive failures, and the count
+// starts over whenever the connection recovers.
+type ReconnectPolicy struct {
+	// MaxFailures is the number of consecutive failures after which the stream is given up. Zero means no limit.
+	MaxFailures int
+	// InitialBackoff is the wait after the first failure. It doubles on every further failure, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes each wait by up to this fraction in either direction, between 0 and 1
+	Jitter float64
+}
+
+// DefaultReconnectPolicy reconnects dropped streams indefinitely, backing off from a second up to a minute so an
+// Envoy reboot or a nightly network outage is ridden out
+var DefaultReconnectPolicy = ReconnectPolicy{
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+	Jitter:         0.2,
+}
+
+// NoReconnect is a ReconnectPolicy that gives up at the first failure
+var NoReconnect = ReconnectPolicy{MaxFailures: 1}
+
+// WithReconnectPolicy sets how StreamMeter and KeepLiveDataStreaming recover from a dropped connection. The default
+// is DefaultReconnectPolicy.
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(c *Client) {
+		c.reconnect = policy
+	}
+}
+
+// exhausted reports whether *failures* consecutive failures are enough to give up
+func (p ReconnectPolicy) exhausted(failures int) bool {
+	return p.MaxFailures > 0 && failures >= p.MaxFailures
+}
+
+// backoff returns how long to wait after failure number *failure*, counting from 1
+func (p ReconnectPolicy) backoff(failure int) time.Duration {
+	return RetryPolicy{InitialBackoff: p.InitialBackoff, MaxBackoff: p.MaxBackoff, Jitter: p.Jitter}.backoff(failure, nil)
+}
+
+// MeterSample is one sample of the /stream/meter event stream, sent about once a second
+type MeterSample struct {
+	// Time is when the sample was received
+	Time             time.Time `json:"-"`
+	Production       Phases    `json:"production,omitempty"`
+	NetConsumption   Phases    `json:"net-consumption,omitempty"`
+	TotalConsumption Phases    `json:"total-consumption,omitempty"`
+}
+
+// StreamMeter subscribes to the Envoy's server-sent meter stream, which carries per-phase readings at about 1 Hz
+// with far lower latency than polling. Samples are delivered on the returned channel, which is closed when *ctx* is
+// done; the Client's timeout does not apply. A dropped stream is reconnected according to the Client's reconnect
+// policy, and the channel is closed once that gives up. An error connecting the first time is returned directly.
+// The stream needs an installer token on most firmware.
+func (c *Client) StreamMeter(ctx context.Context) (<-chan MeterSample, error) {
+	resp, err := c.stream(ctx, "/stream/meter")
+	if err != nil {
+		return nil, err
+	}
+	samples := make(chan MeterSample, 1)
+	go func() {
+		defer close(samples)
+		handle := func(data []byte) bool {
+			sample := MeterSample{Time: time.Now()}
+			if err := json.Unmarshal(data, &sample); err != nil {
+				c.logger.DebugContext(ctx, "skipping malformed meter sample", "error", err)
+				return true
+			}
+			select {
+			case samples <- sample:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		c.resubscribe(ctx, "/stream/meter", resp, handle)
+	}()
+	return samples, nil
+}
+
+// resubscribe reads events from *resp* and reconnects to *path* whenever the stream drops, until *ctx* is done or
+// the reconnect policy gives up
+func (c *Client) resubscribe(ctx context.Context, path string, resp *http.Response, handle func(data []byte) bool) {
+	failures := 0
+	for {
+		if resp != nil {
+			received := false
+			err := readEvents(resp, func(data []byte) bool {
+				received = true
+				return handle(data)
+			})
+			resp.Body.Close()
+			if received {
+				failures = 0
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.DebugContext(ctx, "stream dropped", "path", path, "error", err)
+		}
+		failures++
+		if c.reconnect.exhausted(failures) {
+			c.logger.WarnContext(ctx, "giving up on stream", "path", path, "failures", failures)
+			return
+		}
+		wait := c.reconnect.backoff(failures)
+		if err := sleep(ctx, wait); err != nil {
+			return
+		}
+		var err error
+		if resp, err = c.stream(ctx, path); err != nil {
+			c.logger.DebugContext(ctx, "reconnecting stream failed", "path", path, "error", err)
+			resp = nil
+		}
+	}
+}
+
+// stream opens a long-lived GET of *path*, bounded by *ctx* alone
+func (c *Client) stream(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.URL.Scheme = c.proto
+	req.URL.Host = c.address
+	req.Header.Set("Accept", eventStream)
+	// the stream is never compressed and must not be buffered by the decoder
+	req.Header.Set("Accept-Encoding", "identity")
+	resp, err := c.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// readEvents calls *handle* with the data of each server-sent event in the body of *resp* until the body ends or
+// *handle* returns false
+func readEvents(resp *http.Response, handle func(data []byte) bool) error {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxStreamEvent)
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 && !handle(data) {
+				return nil
+			}
+			data = data[:0]
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		handle(data)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package envoy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStreamReconnectGivesUp(t *testing.T) {
+	f := newFakeEnvoy(t)
+	connects := 0
+	f.handle("/stream/meter", func(w http.ResponseWriter, r *http.Request) {
+		connects++
+		if connects > 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {}\n\n")
+	})
+	c := f.client(WithReconnectPolicy(ReconnectPolicy{MaxFailures: 3, InitialBackoff: time.Millisecond}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	samples, err := c.StreamMeter(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := 0
+	for range samples {
+		received++
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the stream was not given up")
+	}
+	if received != 1 {
+		t.Errorf("received %d samples, want 1", received)
+	}
+	// the drop of the first connection and two failed reconnects make three consecutive failures
+	if n := f.requestCount("/stream/meter"); n != 3 {
+		t.Errorf("connected %d times, want 3", n)
+	}
+}
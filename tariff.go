@@ -0,0 +1,362 @@
+package envoy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// BatteryMode is the operating mode of the batteries
+type BatteryMode string
+
+// Battery operating modes
+const (
+	// BatteryModeSelfConsumption charges from solar and discharges to cover the home's consumption
+	BatteryModeSelfConsumption BatteryMode = "self-consumption"
+	// BatteryModeSavings discharges during expensive time-of-use periods
+	BatteryModeSavings BatteryMode = "economy"
+	// BatteryModeFullBackup keeps the batteries charged for outages
+	BatteryModeFullBackup BatteryMode = "backup"
+)
+
+// StorageSettings are the battery settings embedded in the tariff configuration. The fields carry no omitempty, so
+// that zero values are written back to the Envoy.
+type StorageSettings struct {
+	Mode                 BatteryMode `json:"mode"`
+	OperationModeSubType string      `json:"operation_mode_sub_type"`
+	// ReservedSOC is the backup reserve, in percent
+	ReservedSOC float64 `json:"reserved_soc"`
+	// VeryLowSOC is the shutdown level, in percent
+	VeryLowSOC     int    `json:"very_low_soc"`
+	ChargeFromGrid bool   `json:"charge_from_grid"`
+	Date           string `json:"date"`
+}
+
+// rawObject is a JSON object whose members are kept verbatim, so documents can be modified without losing fields
+// this package does not model
+type rawObject map[string]json.RawMessage
+
+// tariffPath serves the tariff and storage configuration
+const tariffPath = "/admin/lib/tariff"
+
+// rawTariff fetches the tariff document along with its tariff object
+func (c *Client) rawTariff(ctx context.Context) (doc, tariff rawObject, err error) {
+	if err := c.get(ctx, tariffPath, &doc); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(doc["tariff"], &tariff); err != nil {
+		return nil, nil, fmt.Errorf("decoding tariff: %w", err)
+	}
+	return doc, tariff, nil
+}
+
+// storageSettings returns the storage settings from the tariff configuration
+func (c *Client) storageSettings(ctx context.Context) (StorageSettings, error) {
+	var settings StorageSettings
+	_, tariff, err := c.rawTariff(ctx)
+	if err != nil {
+		return settings, err
+	}
+	raw, ok := tariff["storage_settings"]
+	if !ok {
+		return settings, &NotSupportedError{Capability: CapabilityEncharge}
+	}
+	err = json.Unmarshal(raw, &settings)
+	return settings, err
+}
+
+// updateStorageSettings applies *update* to the storage settings and writes the tariff document back, keeping every
+// other field as the Envoy reported it. *action* describes the change for the Client's ConfirmFunc.
+func (c *Client) updateStorageSettings(ctx context.Context, action string, update func(*StorageSettings) error) error {
+	doc, tariff, err := c.rawTariff(ctx)
+	if err != nil {
+		return err
+	}
+	raw, ok := tariff["storage_settings"]
+	if !ok {
+		return &NotSupportedError{Capability: CapabilityEncharge}
+	}
+	var settings StorageSettings
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return err
+	}
+	if err := update(&settings); err != nil {
+		return err
+	}
+	if err := c.confirm(ctx, action); err != nil {
+		return err
+	}
+
+	var storage rawObject
+	if err := json.Unmarshal(raw, &storage); err != nil {
+		return err
+	}
+	if err := mergeInto(storage, settings); err != nil {
+		return err
+	}
+	if tariff["storage_settings"], err = json.Marshal(storage); err != nil {
+		return err
+	}
+	if doc["tariff"], err = json.Marshal(tariff); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, tariffPath, rawObject{"tariff": doc["tariff"]}, nil)
+}
+
+// mergeInto overwrites the members of *obj* with the fields of *v*, which must marshal to a JSON object
+func mergeInto(obj rawObject, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var fields rawObject
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	for k, f := range fields {
+		obj[k] = f
+	}
+	return nil
+}
+
+// GetBatteryMode returns the operating mode of the batteries. Systems without batteries return a *NotSupportedError
+// for CapabilityEncharge.
+func (c *Client) GetBatteryMode(ctx context.Context) (BatteryMode, error) {
+	settings, err := c.storageSettings(ctx)
+	return settings.Mode, err
+}
+
+// SetBatteryMode switches the operating mode of the batteries, e.g. to BatteryModeFullBackup ahead of a storm. The
+// action is subject to the Client's ConfirmFunc.
+func (c *Client) SetBatteryMode(ctx context.Context, mode BatteryMode) error {
+	switch mode {
+	case BatteryModeSelfConsumption, BatteryModeSavings, BatteryModeFullBackup:
+	default:
+		return fmt.Errorf("invalid battery mode %q", mode)
+	}
+	return c.updateStorageSettings(ctx, fmt.Sprintf("set battery mode %s", mode), func(s *StorageSettings) error {
+		s.Mode = mode
+		return nil
+	})
+}
+
+// SetBatteryReserve sets the backup reserve to *percent* of the battery capacity, then reads the setting back to
+// verify the Envoy applied it, returning ErrNotApplied if it did not. The action is subject to the Client's
+// ConfirmFunc.
+func (c *Client) SetBatteryReserve(ctx context.Context, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("invalid battery reserve %d%%", percent)
+	}
+	err := c.updateStorageSettings(ctx, fmt.Sprintf("set battery reserve %d%%", percent), func(s *StorageSettings) error {
+		if percent < s.VeryLowSOC {
+			return fmt.Errorf("battery reserve %d%% is below the shutdown level of %d%%", percent, s.VeryLowSOC)
+		}
+		s.ReservedSOC = float64(percent)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	settings, err := c.storageSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if math.Round(settings.ReservedSOC) != float64(percent) {
+		return fmt.Errorf("%w: battery reserve is %v%%", ErrNotApplied, settings.ReservedSOC)
+	}
+	return nil
+}
+
+// ChargeFromGrid reports whether the batteries may charge from the grid. Systems without batteries return a
+// *NotSupportedError for CapabilityEncharge.
+func (c *Client) ChargeFromGrid(ctx context.Context) (bool, error) {
+	settings, err := c.storageSettings(ctx)
+	return settings.ChargeFromGrid, err
+}
+
+// SetChargeFromGrid allows or forbids charging the batteries from the grid, then reads the setting back, returning
+// ErrNotApplied if it did not take. It is only configurable in self-consumption and savings modes; in full backup
+// mode the batteries always charge from the grid. The action is subject to the Client's ConfirmFunc.
+func (c *Client) SetChargeFromGrid(ctx context.Context, enabled bool) error {
+	err := c.updateStorageSettings(ctx, fmt.Sprintf("set charge from grid %t", enabled), func(s *StorageSettings) error {
+		if s.Mode == BatteryModeFullBackup {
+			return fmt.Errorf("charge from grid is not configurable in %s mode", s.Mode)
+		}
+		s.ChargeFromGrid = enabled
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	applied, err := c.ChargeFromGrid(ctx)
+	if err != nil {
+		return err
+	}
+	if applied != enabled {
+		return fmt.Errorf("%w: charge from grid is %t", ErrNotApplied, applied)
+	}
+	return nil
+}
+
+// Tariff is the tariff configuration from /admin/lib/tariff. Like StorageSettings, its fields carry no omitempty so
+// that it can be written back with UpdateTariff.
+type Tariff struct {
+	Currency        Currency        `json:"currency"`
+	Logger          string          `json:"logger"`
+	Date            string          `json:"date"`
+	StorageSettings StorageSettings `json:"storage_settings"`
+	SingleRate      SingleRate      `json:"single_rate"`
+	// Seasons are the time-of-use buy rates and SeasonsSell the sell rates
+	Seasons     []TariffSeason `json:"seasons"`
+	SeasonsSell []TariffSeason `json:"seasons_sell"`
+}
+
+// Currency identifies the currency of the rates
+type Currency struct {
+	// Code is an ISO 4217 code such as USD
+	Code string `json:"code"`
+}
+
+// SingleRate are the buy and sell rates of a flat tariff, per kWh
+type SingleRate struct {
+	Rate float64 `json:"rate"`
+	Sell float64 `json:"sell"`
+}
+
+// TariffSeason is a part of the year with its own time-of-use schedule
+type TariffSeason struct {
+	ID string `json:"id"`
+	// Start is the first day of the season, as month/day
+	Start string       `json:"start"`
+	Days  []TariffDays `json:"days"`
+	Tiers []TariffTier `json:"tiers"`
+}
+
+// TariffDays is the schedule for a set of days of the week within a season
+type TariffDays struct {
+	ID string `json:"id"`
+	// Days lists the days, e.g. Mon,Tue,Wed,Thu,Fri
+	Days                  string         `json:"days"`
+	MustChargeStart       int            `json:"must_charge_start"`
+	MustChargeDuration    int            `json:"must_charge_duration"`
+	MustChargeMode        string         `json:"must_charge_mode"`
+	EnableDischargeToGrid bool           `json:"enable_discharge_to_grid"`
+	Periods               []TariffPeriod `json:"periods"`
+}
+
+// TariffPeriod is a time-of-use period starting at Start minutes after midnight and lasting until the next period
+type TariffPeriod struct {
+	ID    string  `json:"id"`
+	Start int     `json:"start"`
+	Rate  float64 `json:"rate"`
+}
+
+// TariffTier is a consumption tier with its own rate
+type TariffTier struct {
+	ID    string  `json:"id"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Rate  float64 `json:"rate"`
+}
+
+// RateAt returns the time-of-use buy rate in effect during *season* on *day* (Mon, Tue, ...) at *minute* minutes after
+// midnight, and false if the tariff has no matching period.
+func (s TariffSeason) RateAt(day string, minute int) (float64, bool) {
+	for _, days := range s.Days {
+		if !strings.Contains(days.Days, day) {
+			continue
+		}
+		rate, found := 0.0, false
+		for _, p := range days.Periods {
+			if p.Start <= minute {
+				rate, found = p.Rate, true
+			}
+		}
+		return rate, found
+	}
+	return 0, false
+}
+
+// Tariff returns the tariff configuration: currency, time-of-use seasons and periods, buy and sell rates, and the
+// storage settings embedded within.
+func (c *Client) Tariff(ctx context.Context) (Tariff, error) {
+	var doc struct {
+		Tariff Tariff `json:"tariff"`
+	}
+	err := c.get(ctx, tariffPath, &doc)
+	return doc.Tariff, err
+}
+
+// Validate checks that the schedule of the tariff is well formed: seasons start on valid dates, every day of the week
+// appears at most once per season, and periods start within the day in ascending order.
+func (t Tariff) Validate() error {
+	validDays := map[string]bool{"Mon": true, "Tue": true, "Wed": true, "Thu": true, "Fri": true, "Sat": true, "Sun": true}
+	for _, seasons := range [][]TariffSeason{t.Seasons, t.SeasonsSell} {
+		for _, season := range seasons {
+			var month, day int
+			if n, _ := fmt.Sscanf(season.Start, "%d/%d", &month, &day); n != 2 || month < 1 || month > 12 || day < 1 || day > 31 {
+				return fmt.Errorf("season %s: invalid start %q", season.ID, season.Start)
+			}
+			seen := make(map[string]bool)
+			for _, days := range season.Days {
+				for _, d := range strings.Split(days.Days, ",") {
+					d = strings.TrimSpace(d)
+					if !validDays[d] {
+						return fmt.Errorf("season %s: invalid day %q", season.ID, d)
+					}
+					if seen[d] {
+						return fmt.Errorf("season %s: day %s is scheduled twice", season.ID, d)
+					}
+					seen[d] = true
+				}
+				last := -1
+				for _, p := range days.Periods {
+					if p.Start <= last || p.Start < 0 || p.Start >= 24*60 {
+						return fmt.Errorf("season %s, days %s: period %s starts at invalid minute %d", season.ID, days.ID, p.ID, p.Start)
+					}
+					if p.Rate < 0 {
+						return fmt.Errorf("season %s, days %s: period %s has negative rate", season.ID, days.ID, p.ID)
+					}
+					last = p.Start
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateTariff validates *tariff* and writes it to the Envoy, keeping any fields of the current configuration this
+// package does not model. The action is subject to the Client's ConfirmFunc.
+func (c *Client) UpdateTariff(ctx context.Context, tariff Tariff) error {
+	if err := tariff.Validate(); err != nil {
+		return err
+	}
+	_, current, err := c.rawTariff(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.confirm(ctx, "update tariff"); err != nil {
+		return err
+	}
+	storage := rawObject{}
+	if raw, ok := current["storage_settings"]; ok {
+		if err := json.Unmarshal(raw, &storage); err != nil {
+			return err
+		}
+	}
+	if err := mergeInto(storage, tariff.StorageSettings); err != nil {
+		return err
+	}
+	if err := mergeInto(current, tariff); err != nil {
+		return err
+	}
+	if current["storage_settings"], err = json.Marshal(storage); err != nil {
+		return err
+	}
+	return c.call(ctx, http.MethodPut, tariffPath, struct {
+		Tariff rawObject `json:"tariff"`
+	}{current}, nil)
+}
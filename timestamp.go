@@ -0,0 +1,126 @@
+package envoy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Timestamp is a time the Envoy reports in seconds since the epoch. It decodes from and encodes to that number, with
+// 0 standing for the zero time. Timestamps are in UTC unless the Client localizes them with WithLocation or
+// WithEnvoyLocation.
+type Timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON decodes seconds since the epoch, given as a number or a numeric string
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	if len(b) == 0 || string(b) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	secs, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(b), Type: reflect.TypeOf(t)}
+	}
+	if secs == 0 {
+		t.Time = time.Time{}
+		return nil
+	}
+	t.Time = time.Unix(int64(secs), 0).UTC()
+	return nil
+}
+
+// MarshalJSON encodes the time as seconds since the epoch
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("0"), nil
+	}
+	return strconv.AppendInt(nil, t.Unix(), 10), nil
+}
+
+// WithLocation presents every Timestamp the Client decodes, and the times of the event log, in *loc*
+func WithLocation(loc *time.Location) Option {
+	return func(c *Client) {
+		c.presentLoc = loc
+		c.localTime = true
+	}
+}
+
+// WithEnvoyLocation presents every Timestamp the Client decodes, and the times of the event log, in the timezone the
+// Envoy is configured for, as returned by Location. If the timezone cannot be read, they stay in UTC until it can.
+func WithEnvoyLocation() Option {
+	return func(c *Client) {
+		c.localTime = true
+	}
+}
+
+// timeLocation returns the location to present times in
+func (c *Client) timeLocation(ctx context.Context) *time.Location {
+	switch {
+	case c.presentLoc != nil:
+		return c.presentLoc
+	case !c.localTime:
+		return time.UTC
+	}
+	loc, err := c.Location(ctx)
+	if err != nil {
+		// Location caches only success, so the timezone is read again on the next call
+		c.logger.WarnContext(ctx, "reading the envoy's timezone failed, leaving times in UTC", "error", err)
+		return time.UTC
+	}
+	return loc
+}
+
+var timestampType = reflect.TypeOf(Timestamp{})
+
+// localizeTimes moves every Timestamp within *response* to the Client's location, if it has one
+func (c *Client) localizeTimes(ctx context.Context, url string, response interface{}) {
+	if !c.localTime || response == nil || url == dateTimePath {
+		return
+	}
+	localize(reflect.ValueOf(response), c.timeLocation(ctx))
+}
+
+func localize(v reflect.Value, loc *time.Location) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			localize(v.Elem(), loc)
+		}
+	case reflect.Struct:
+		if v.Type() == timestampType {
+			if t := v.Interface().(Timestamp); v.CanSet() && !t.IsZero() {
+				v.Set(reflect.ValueOf(Timestamp{t.In(loc)}))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				localize(f, loc)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			localize(v.Index(i), loc)
+		}
+	case reflect.Map:
+		switch v.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		default:
+			return
+		}
+		// map values are not addressable, so localize a copy and store it back
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			localize(e, loc)
+			v.SetMapIndex(iter.Key(), e)
+		}
+	}
+}
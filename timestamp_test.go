@@ -0,0 +1,63 @@
+package envoy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+type stamped struct {
+	At Timestamp `json:"at"`
+}
+
+func TestWithLocationKeepsEnvoyTimezone(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handleJSON(dateTimePath, `{"tz":"US/Pacific"}`)
+	present := time.FixedZone("UTC+1", 3600)
+	c := f.client(WithLocation(present))
+	ctx := context.Background()
+
+	loc, err := c.Location(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.String() != "US/Pacific" {
+		t.Errorf("Location returned %s, want the Envoy's US/Pacific", loc)
+	}
+	f.handleJSON("/stamped", `{"at":1700000000}`)
+	var v stamped
+	if err := c.GetJSON(ctx, "/stamped", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.At.Location() != present {
+		t.Errorf("timestamp presented in %s, want %s", v.At.Location(), present)
+	}
+}
+
+func TestEnvoyLocationRetriesAfterFailure(t *testing.T) {
+	f := newFakeEnvoy(t)
+	f.handle(dateTimePath, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	})
+	f.handleJSON("/stamped", `{"at":1700000000}`)
+	c := f.client(WithEnvoyLocation())
+	ctx := context.Background()
+
+	var v stamped
+	if err := c.GetJSON(ctx, "/stamped", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.At.Location() != time.UTC {
+		t.Errorf("timestamp presented in %s while the timezone is unknown, want UTC", v.At.Location())
+	}
+
+	f.handleJSON(dateTimePath, `{"tz":"US/Pacific"}`)
+	if err := c.GetJSON(ctx, "/stamped", &v); err != nil {
+		t.Fatal(err)
+	}
+	if name := v.At.Location().String(); name != "US/Pacific" {
+		t.Errorf("timestamp presented in %s once the timezone could be read, want US/Pacific", name)
+	}
+}
@@ -0,0 +1,95 @@
+package envoy
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultTokenRefreshMargin is how long before expiry the Client asks its TokenProvider for a new token.
+const DefaultTokenRefreshMargin = time.Hour
+
+// TokenProvider supplies the JWTs used to authenticate against the Envoy
+type TokenProvider interface {
+	// Token returns a currently valid token
+	Token(ctx context.Context) (string, error)
+}
+
+// WithTokenProvider makes the Client fetch its token from *provider*, and fetch a new one shortly before the current
+// token expires or when the Envoy rejects it.
+func WithTokenProvider(provider TokenProvider) Option {
+	return func(c *Client) {
+		c.tokenProvider = provider
+	}
+}
+
+// WithTokenRefreshMargin sets how long before expiry a new token is fetched. The default is DefaultTokenRefreshMargin.
+func WithTokenRefreshMargin(margin time.Duration) Option {
+	return func(c *Client) {
+		c.refreshMargin = margin
+	}
+}
+
+// refreshToken fetches a new token from the configured TokenProvider if the current one is missing or about to
+// expire, or unconditionally if *force* is set.
+func (c *Client) refreshToken(ctx context.Context, force bool) error {
+	if c.tokenProvider == nil {
+		return nil
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	c.mu.Lock()
+	token, expiry := c.token, c.tokenExpiry
+	c.mu.Unlock()
+	if !force && token != "" && (expiry.IsZero() || time.Until(expiry) > c.refreshMargin) {
+		return nil
+	}
+
+	c.logger.DebugContext(ctx, "fetching new token", "expiry", expiry, "forced", force)
+	if force {
+		// the current token was rejected, so a provider must not serve it again from a store
+		ctx = ForceRefresh(ctx)
+	}
+	token, err := c.tokenProvider.Token(ctx)
+	if err != nil {
+		return err
+	}
+	c.SetToken(token)
+	return nil
+}
+
+// StaticToken is a TokenProvider that always returns the same token
+type StaticToken string
+
+// Token returns the token itself
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// FileToken is a TokenProvider that reads the token from a file each time one is needed, so the file can be replaced
+// while the Client is running.
+type FileToken string
+
+// Token returns the contents of the file, with surrounding whitespace removed
+func (f FileToken) Token(ctx context.Context) (string, error) {
+	b, err := os.ReadFile(string(f))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// EnlightenToken is a TokenProvider that fetches a new token from the Enlighten cloud with FetchToken
+type EnlightenToken struct {
+	Email    string
+	Password string
+	// Serial is the serial number of the Envoy the token is issued for
+	Serial string
+}
+
+// Token fetches a new token from Enlighten
+func (e EnlightenToken) Token(ctx context.Context) (string, error) {
+	return FetchToken(ctx, e.Email, e.Password, e.Serial)
+}
@@ -7,15 +7,16 @@ type DevControl struct {
 
 // Device describes a device attached to the Envoy system
 type Device struct {
-	PartNum        string       `json:"part_num,omitempty"`
-	Installed      int          `json:"installed,omitempty"`
-	SerialNum      int          `json:"serial_num,omitempty"`
-	DeviceStatus   []string     `json:"device_status,omitempty"`
-	LastReportDate int          `json:"last_report_date,omitempty"`
+	PartNum string `json:"part_num,omitempty"`
+	// Installed is when the device was registered with the Envoy
+	Installed      Timestamp    `json:"installed,omitempty"`
+	SerialNum      string       `json:"serial_num,omitempty"`
+	DeviceStatus   Conditions   `json:"device_status,omitempty"`
+	LastReportDate Timestamp    `json:"last_rpt_date,omitempty"`
 	AdminState     int          `json:"admin_state,omitempty"`
 	DevType        int          `json:"dev_type,omitempty"`
-	CreatedDate    int          `json:"created_date,omitempty"`
-	ImgLoadDate    int          `json:"img_load_date,omitempty"`
+	CreatedDate    Timestamp    `json:"created_date,omitempty"`
+	ImgLoadDate    Timestamp    `json:"img_load_date,omitempty"`
 	ImgPnumRunning string       `json:"img_pnum_running,omitempty"`
 	Ptpn           string       `json:"ptpn,omitempty"`
 	Chaneid        int          `json:"chaneid,omitempty"`
@@ -24,41 +25,79 @@ type Device struct {
 	Communicating  bool         `json:"communicating,omitempty"`
 	Provisioned    bool         `json:"provisioned,omitempty"`
 	Operating      bool         `json:"operating,omitempty"`
+	// Deleted is set for a retired device, listed only when InventoryOptions.IncludeDeleted is
+	Deleted bool `json:"deleted,omitempty"`
 }
 
-// Inventory describes a list of Devices of a certain Type
-type Inventory struct {
+// DeviceGroup describes a list of Devices of a certain Type
+type DeviceGroup struct {
 	Type    string   `json:"type,omitempty"`
 	Devices []Device `json:"devices,omitempty"`
 }
 
+// Production reading types
+const (
+	// ProductionTypeInverters is the production estimated from the microinverter reports
+	ProductionTypeInverters = "inverters"
+	// ProductionTypeEIM is a reading of the integrated CT meter
+	ProductionTypeEIM = "eim"
+	// ProductionTypeACB is the state of AC batteries
+	ProductionTypeACB = "acb"
+)
+
 // ProductionData describes a power reading from a particular sensor. May be a consumption meter.
+// Inverter readings carry only the counts, time, wNow and whLifetime; the rest are set by meters.
 type ProductionData struct {
-	Type             string  `json:"type,omitempty"`
-	ActiveCount      int     `json:"activeCount,omitempty"`
-	MeasurementType  string  `json:"measurementType,omitempty"`
-	ReadingTime      int     `json:"readingTime,omitempty"`
-	WNow             float64 `json:"wNow,omitempty"`
-	WhLifetime       float64 `json:"whLifetime,omitempty"`
+	// Type is one of the ProductionType constants
+	Type string `json:"type,omitempty"`
+	// ActiveCount is the number of devices contributing to the reading
+	ActiveCount int `json:"activeCount,omitempty"`
+	// MeasurementType is one of the Measurement constants, for meter readings
+	MeasurementType string `json:"measurementType,omitempty"`
+	// ReadingTime is when the reading was taken
+	ReadingTime Timestamp `json:"readingTime,omitempty"`
+	// WNow is the real power, in W
+	WNow Watts `json:"wNow,omitempty"`
+	// WhLifetime is the real energy since the meter was installed, in Wh
+	WhLifetime WattHours `json:"whLifetime,omitempty"`
+	// VarhLeadLifetime and VarhLagLifetime are the leading and lagging reactive energy, in varh
 	VarhLeadLifetime float64 `json:"varhLeadLifetime,omitempty"`
 	VarhLagLifetime  float64 `json:"varhLagLifetime,omitempty"`
-	VahLifetime      float64 `json:"vahLifetime,omitempty"`
-	RmsCurrent       float64 `json:"rmsCurrent,omitempty"`
-	RmsVoltage       float64 `json:"rmsVoltage,omitempty"`
-	ReactPwr         float64 `json:"reactPwr,omitempty"`
-	ApprntPwr        float64 `json:"apprntPwr,omitempty"`
-	PwrFactor        float64 `json:"pwrFactor,omitempty"`
-	WhToday          float64 `json:"whToday,omitempty"`
-	WhLastSevenDays  float64 `json:"whLastSevenDays,omitempty"`
-	VahToday         float64 `json:"vahToday,omitempty"`
-	VarhLeadToday    float64 `json:"varhLeadToday,omitempty"`
-	VarhLagToday     float64 `json:"varhLagToday,omitempty"`
-	State            string  `json:"state,omitempty"`
+	// VahLifetime is the apparent energy, in VAh
+	VahLifetime float64 `json:"vahLifetime,omitempty"`
+	// RmsCurrent is the current, in A, summed over the phases
+	RmsCurrent Amps `json:"rmsCurrent,omitempty"`
+	// RmsVoltage is the voltage, in V, summed over the phases; divide by len(Lines) for the per-phase voltage
+	RmsVoltage Volts `json:"rmsVoltage,omitempty"`
+	// ReactPwr is the reactive power, in var
+	ReactPwr float64 `json:"reactPwr,omitempty"`
+	// ApprntPwr is the apparent power, in VA
+	ApprntPwr float64 `json:"apprntPwr,omitempty"`
+	// PwrFactor is the power factor, between -1 and 1
+	PwrFactor float64 `json:"pwrFactor,omitempty"`
+	// WhToday and WhLastSevenDays are the real energy since local midnight and over the last seven days, in Wh
+	WhToday         WattHours `json:"whToday,omitempty"`
+	WhLastSevenDays WattHours `json:"whLastSevenDays,omitempty"`
+	// VahToday, VarhLeadToday and VarhLagToday are the apparent and reactive energy since local midnight
+	VahToday      float64 `json:"vahToday,omitempty"`
+	VarhLeadToday float64 `json:"varhLeadToday,omitempty"`
+	VarhLagToday  float64 `json:"varhLagToday,omitempty"`
+	// WhNow is the energy stored, in Wh, for ProductionTypeACB
+	WhNow WattHours `json:"whNow,omitempty"`
+	// PercentFull is the state of charge, for ProductionTypeACB
+	PercentFull int `json:"percentFull,omitempty"`
+	// State is e.g. idle, charging or discharging for ProductionTypeACB
+	State string `json:"state,omitempty"`
+	// Lines are the same values per phase, on multi-phase meters
+	Lines []ProductionData `json:"lines,omitempty"`
 }
 
 // Production is the collection of all power sensors in the system.
 type Production struct {
-	Production  []ProductionData `json:"production,omitempty"`
+	// Production holds the inverter estimate and, if there is a production CT, the meter reading
+	Production []ProductionData `json:"production,omitempty"`
+	// Consumption holds the total and net consumption meter readings, if there are consumption CTs
 	Consumption []ProductionData `json:"consumption,omitempty"`
-	Storage     []ProductionData `json:"storage,omitempty"`
+	// Storage holds the AC battery readings
+	Storage []ProductionData `json:"storage,omitempty"`
 }
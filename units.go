@@ -0,0 +1,77 @@
+package envoy
+
+import (
+	"math"
+	"strconv"
+)
+
+// Watts is real power in W
+type Watts float64
+
+// Kilowatts returns *kw* kW as Watts
+func Kilowatts(kw float64) Watts {
+	return Watts(kw * 1000)
+}
+
+// Kilowatts returns the power in kW
+func (w Watts) Kilowatts() float64 {
+	return float64(w) / 1000
+}
+
+// String formats the power in W, or kW from 1 kW up, e.g. 450 W or 3.25 kW
+func (w Watts) String() string {
+	if w >= 1000 || w <= -1000 {
+		return formatUnit(w.Kilowatts(), "kW")
+	}
+	return formatUnit(float64(w), "W")
+}
+
+// WattHours is real energy in Wh
+type WattHours float64
+
+// KilowattHours returns *kwh* kWh as WattHours
+func KilowattHours(kwh float64) WattHours {
+	return WattHours(kwh * 1000)
+}
+
+// KilowattHours returns the energy in kWh
+func (wh WattHours) KilowattHours() float64 {
+	return float64(wh) / 1000
+}
+
+// MegawattHours returns the energy in MWh
+func (wh WattHours) MegawattHours() float64 {
+	return float64(wh) / 1e6
+}
+
+// String formats the energy in Wh, kWh or MWh, whichever keeps the number below 1000, e.g. 12.5 kWh
+func (wh WattHours) String() string {
+	switch {
+	case wh >= 1e6 || wh <= -1e6:
+		return formatUnit(wh.MegawattHours(), "MWh")
+	case wh >= 1000 || wh <= -1000:
+		return formatUnit(wh.KilowattHours(), "kWh")
+	}
+	return formatUnit(float64(wh), "Wh")
+}
+
+// Volts is voltage in V
+type Volts float64
+
+// String formats the voltage, e.g. 240.1 V
+func (v Volts) String() string {
+	return formatUnit(float64(v), "V")
+}
+
+// Amps is current in A
+type Amps float64
+
+// String formats the current, e.g. 12.3 A
+func (a Amps) String() string {
+	return formatUnit(float64(a), "A")
+}
+
+// formatUnit formats *v* with at most two decimals, followed by *unit*
+func formatUnit(v float64, unit string) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64) + " " + unit
+}
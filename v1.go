@@ -0,0 +1,104 @@
+package envoy
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// SystemProduction is the lightweight production summary from /api/v1/production. It is computed from the
+// microinverter reports, so it works on systems without production CTs.
+type SystemProduction struct {
+	WattHoursToday     int `json:"wattHoursToday,omitempty"`
+	WattHoursSevenDays int `json:"wattHoursSevenDays,omitempty"`
+	WattHoursLifetime  int `json:"wattHoursLifetime,omitempty"`
+	WattsNow           int `json:"wattsNow,omitempty"`
+}
+
+// SystemProduction returns the current and cumulative production of the system.
+func (c *Client) SystemProduction(ctx context.Context) (SystemProduction, error) {
+	var production SystemProduction
+	err := c.get(ctx, systemProductionPath, &production)
+	return production, err
+}
+
+// SystemConsumption is the consumption summary from /api/v1/consumption, available on systems with consumption CTs.
+type SystemConsumption struct {
+	WattHoursToday     int `json:"wattHoursToday,omitempty"`
+	WattHoursSevenDays int `json:"wattHoursSevenDays,omitempty"`
+	WattHoursLifetime  int `json:"wattHoursLifetime,omitempty"`
+	WattsNow           int `json:"wattsNow,omitempty"`
+}
+
+// SystemConsumption returns the current and cumulative consumption of the home. Systems without consumption CTs
+// return a *NotSupportedError for CapabilityMeters.
+func (c *Client) SystemConsumption(ctx context.Context) (SystemConsumption, error) {
+	var consumption SystemConsumption
+	err := c.getFeature(ctx, CapabilityMeters, "/api/v1/consumption", &consumption)
+	return consumption, err
+}
+
+// Inverter is the latest report of a single microinverter from /api/v1/production/inverters
+type Inverter struct {
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// LastReportDate is when the inverter last reported
+	LastReportDate  Timestamp `json:"lastReportDate,omitempty"`
+	DevType         int       `json:"devType,omitempty"`
+	LastReportWatts int       `json:"lastReportWatts,omitempty"`
+	MaxReportWatts  int       `json:"maxReportWatts,omitempty"`
+}
+
+// Inverters returns the latest production report of every microinverter.
+func (c *Client) Inverters(ctx context.Context) ([]Inverter, error) {
+	var inverters []Inverter
+	err := c.get(ctx, "/api/v1/production/inverters", &inverters)
+	return inverters, err
+}
+
+// LastReportAge returns how long ago the inverter last reported
+func (i Inverter) LastReportAge() time.Duration {
+	return time.Since(i.LastReportDate.Time)
+}
+
+// IsStale reports whether the inverter has not reported for longer than *threshold*
+func (i Inverter) IsStale(threshold time.Duration) bool {
+	return i.LastReportAge() > threshold
+}
+
+// PercentOfMax returns the last reported power as a percentage of the most the inverter has ever reported, or 0 if
+// it has never reported any
+func (i Inverter) PercentOfMax() float64 {
+	if i.MaxReportWatts <= 0 {
+		return 0
+	}
+	return float64(i.LastReportWatts) / float64(i.MaxReportWatts) * 100
+}
+
+// Underperformers returns the inverters whose last report is below *fraction* of the median of their peers, e.g.
+// 0.7 for 30% below, which points at shading, soiling or a failing panel. Since panels facing different ways
+// produce differently, pass the inverters of one array at a time. It returns nil while the median is zero, as at
+// night.
+func Underperformers(inverters []Inverter, fraction float64) []Inverter {
+	if len(inverters) < 2 {
+		return nil
+	}
+	watts := make([]int, len(inverters))
+	for n, i := range inverters {
+		watts[n] = i.LastReportWatts
+	}
+	sort.Ints(watts)
+	median := float64(watts[len(watts)/2])
+	if len(watts)%2 == 0 {
+		median = float64(watts[len(watts)/2-1]+watts[len(watts)/2]) / 2
+	}
+	if median <= 0 {
+		return nil
+	}
+	var under []Inverter
+	for _, i := range inverters {
+		if float64(i.LastReportWatts) < fraction*median {
+			under = append(under, i)
+		}
+	}
+	return under
+}
@@ -0,0 +1,210 @@
+package envoy
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultPowerDelta is the change in W a Watcher reports unless told otherwise
+const DefaultPowerDelta = 50
+
+// DefaultStaleAfter is how long an inverter may go without reporting before a Watcher considers it offline. The
+// microinverters report about every five minutes while producing.
+const DefaultStaleAfter = 20 * time.Minute
+
+// ChangeKind is the kind of a Change
+type ChangeKind string
+
+// Kinds of Change
+const (
+	// ChangeProduction is a change in production power
+	ChangeProduction ChangeKind = "production"
+	// ChangeConsumption is a change in total consumption power
+	ChangeConsumption ChangeKind = "consumption"
+	// ChangeOffline is a device that stopped reporting or communicating
+	ChangeOffline ChangeKind = "offline"
+	// ChangeOnline is a device that is reporting or communicating again
+	ChangeOnline ChangeKind = "online"
+)
+
+// Change is a notable difference between successive polls
+type Change struct {
+	Kind ChangeKind
+	// Source is the name of the Source whose Update revealed the change
+	Source string
+	Time   time.Time
+	// Serial is the device affected by ChangeOffline and ChangeOnline
+	Serial string
+	// Old and New are the previously reported and current power, for ChangeProduction and ChangeConsumption
+	Old, New Watts
+}
+
+// Watcher compares successive Updates and reports only the Changes that matter, such as production moving by more
+// than PowerDelta or an inverter going offline. The first Update of each kind sets the baseline. Microinverters stop
+// reporting at night, so like an OfflineMonitor it only follows them in daylight. The zero value is ready to use.
+type Watcher struct {
+	// PowerDelta is the smallest change that is reported, DefaultPowerDelta if zero
+	PowerDelta Watts
+	// StaleAfter is how old an inverter's last report may be before it is offline, DefaultStaleAfter if zero
+	StaleAfter time.Duration
+	// Daylight reports whether inverters should be producing at a given time, e.g. SunDaylight for the site. If
+	// nil, it is daylight whenever any inverter is producing. Outside daylight, microinverters neither go offline
+	// nor come back.
+	Daylight func(time.Time) bool
+
+	mu      sync.Mutex
+	power   map[ChangeKind]Watts
+	devices map[string]bool
+}
+
+// Watch observes every Update from *updates*, e.g. a Poller subscription, and delivers the resulting Changes on the
+// returned channel, which is closed when *updates* is closed or *ctx* is done
+func (w *Watcher) Watch(ctx context.Context, updates <-chan Update) <-chan Change {
+	changes := make(chan Change)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u, ok := <-updates:
+				if !ok {
+					return
+				}
+				for _, change := range w.Observe(u) {
+					select {
+					case changes <- change:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return changes
+}
+
+// Observe compares *u* with the earlier Updates and returns what changed. Values other than Production, []Inverter
+// and Inventory, and failed polls, are ignored.
+func (w *Watcher) Observe(u Update) []Change {
+	if u.Err != nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.power == nil {
+		w.power = make(map[ChangeKind]Watts)
+		w.devices = make(map[string]bool)
+	}
+	var changes []Change
+	switch v := u.Value.(type) {
+	case Production:
+		if d, ok := currentWatts(v.Production); ok {
+			changes = w.observePower(changes, u, ChangeProduction, d)
+		}
+		if d, ok := currentWatts(consumptionOf(v, MeasurementTotalConsumption)); ok {
+			changes = w.observePower(changes, u, ChangeConsumption, d)
+		}
+	case []Inverter:
+		stale := w.StaleAfter
+		if stale <= 0 {
+			stale = DefaultStaleAfter
+		}
+		if !w.daylight(u.Time, func() bool { return anyProducing(u.Time, v, stale) }) {
+			break
+		}
+		for _, inv := range v {
+			online := u.Time.Sub(inv.LastReportDate.Time) <= stale
+			changes = w.observeDevice(changes, u, inv.SerialNumber, online)
+		}
+	case Inventory:
+		night := !w.daylight(u.Time, func() bool {
+			for _, m := range v.Microinverters {
+				if m.Producing {
+					return true
+				}
+			}
+			return false
+		})
+		unpowered := make(map[string]bool)
+		for _, m := range v.Microinverters {
+			unpowered[m.SerialNum] = night
+		}
+		for _, d := range v.Devices() {
+			if d.Deleted || unpowered[d.SerialNum] {
+				// a replaced device stays in the inventory but never reports again, and microinverters go quiet
+				// at night
+				continue
+			}
+			changes = w.observeDevice(changes, u, d.SerialNum, d.Communicating)
+		}
+	}
+	return changes
+}
+
+// daylight reports whether it is daylight at *t*, asking Daylight if set and *producing* otherwise
+func (w *Watcher) daylight(t time.Time, producing func() bool) bool {
+	if w.Daylight != nil {
+		return w.Daylight(t)
+	}
+	return producing()
+}
+
+func (w *Watcher) observePower(changes []Change, u Update, kind ChangeKind, watts Watts) []Change {
+	delta := w.PowerDelta
+	if delta <= 0 {
+		delta = DefaultPowerDelta
+	}
+	old, seen := w.power[kind]
+	if !seen {
+		w.power[kind] = watts
+		return changes
+	}
+	if Watts(math.Abs(float64(watts-old))) < delta {
+		return changes
+	}
+	w.power[kind] = watts
+	return append(changes, Change{Kind: kind, Source: u.Source, Time: u.Time, Old: old, New: watts})
+}
+
+func (w *Watcher) observeDevice(changes []Change, u Update, serial string, online bool) []Change {
+	was, seen := w.devices[serial]
+	w.devices[serial] = online
+	if !seen || was == online {
+		return changes
+	}
+	kind := ChangeOffline
+	if online {
+		kind = ChangeOnline
+	}
+	return append(changes, Change{Kind: kind, Source: u.Source, Time: u.Time, Serial: serial})
+}
+
+// currentWatts returns the power of the most accurate reading in *data*: the meter if there is one, otherwise the
+// inverters' estimate
+func currentWatts(data []ProductionData) (Watts, bool) {
+	var watts Watts
+	found := false
+	for _, d := range data {
+		if d.Type == ProductionTypeEIM {
+			return d.WNow, true
+		}
+		if !found {
+			watts, found = d.WNow, true
+		}
+	}
+	return watts, found
+}
+
+// consumptionOf returns the consumption readings of *p* with the given measurement type
+func consumptionOf(p Production, measurementType string) []ProductionData {
+	var data []ProductionData
+	for _, d := range p.Consumption {
+		if d.MeasurementType == measurementType {
+			data = append(data, d)
+		}
+	}
+	return data
+}
@@ -0,0 +1,52 @@
+package envoy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatcherNight(t *testing.T) {
+	var w Watcher
+	noon := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	inverters := func(now, reported time.Time, watts int) Update {
+		return Update{Source: "inverters", Time: now, Value: []Inverter{
+			{SerialNumber: "1", LastReportDate: Timestamp{reported}, LastReportWatts: watts},
+			{SerialNumber: "2", LastReportDate: Timestamp{reported}, LastReportWatts: watts},
+		}}
+	}
+	w.Observe(inverters(noon, noon, 250))
+
+	// at dusk the inverters stop reporting together
+	dusk := noon.Add(8 * time.Hour)
+	if changes := w.Observe(inverters(dusk.Add(time.Hour), dusk, 0)); len(changes) != 0 {
+		t.Errorf("got %+v at night, want no changes", changes)
+	}
+	dawn := noon.Add(18 * time.Hour)
+	if changes := w.Observe(inverters(dawn, dawn, 20)); len(changes) != 0 {
+		t.Errorf("got %+v at dawn, want no changes", changes)
+	}
+
+	// in daylight one inverter falling silent is reported
+	later := dawn.Add(time.Hour)
+	u := inverters(later, later, 250)
+	u.Value.([]Inverter)[1].LastReportDate = Timestamp{dawn}
+	if changes := w.Observe(u); len(changes) != 1 || changes[0].Serial != "2" || changes[0].Kind != ChangeOffline {
+		t.Errorf("got %+v, want inverter 2 going offline", changes)
+	}
+}
+
+func TestWatcherInventoryNight(t *testing.T) {
+	var w Watcher
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	inventory := func(producing bool) Inventory {
+		return Inventory{
+			Microinverters: []Microinverter{{Device: Device{SerialNum: "1", Producing: producing, Communicating: producing}}},
+			NetworkRelays:  []NetworkRelay{{Device: Device{SerialNum: "9", Communicating: producing}}},
+		}
+	}
+	w.Observe(Update{Source: "inventory", Time: now, Value: inventory(true)})
+	changes := w.Observe(Update{Source: "inventory", Time: now.Add(9 * time.Hour), Value: inventory(false)})
+	if len(changes) != 1 || changes[0].Serial != "9" {
+		t.Errorf("got %+v at night, want only the relay going offline", changes)
+	}
+}